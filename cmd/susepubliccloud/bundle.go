@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/bundle"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/mirror"
+)
+
+// runBundle dispatches the subcommands handling signed snapshot bundles
+func runBundle(args []string, stdout io.Writer) error {
+	subcommands := map[string]func([]string, io.Writer) error{
+		"keygen": runBundleKeygen,
+		"export": runBundleExport,
+		"verify": runBundleVerify,
+	}
+
+	if len(args) > 0 {
+		if run, ok := subcommands[args[0]]; ok {
+			return run(args[1:], stdout)
+		}
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Usage: susepubliccloud bundle <keygen|export|verify> [flags]\n")
+	return fmt.Errorf("expected one of the keygen, export or verify subcommands")
+}
+
+func runBundleKeygen(args []string, stdout io.Writer) error {
+	var prefix string
+
+	fs := flag.NewFlagSet("bundle keygen", flag.ContinueOnError)
+	fs.StringVar(&prefix, "out", "", "Prefix of the key files, PREFIX.key and PREFIX.pub are written (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if prefix == "" {
+		return fmt.Errorf("the --out flag is required")
+	}
+
+	public, private, err := bundle.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(prefix+".key", private, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(prefix+".pub", public, 0644); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Written %s.key and %s.pub\n", prefix, prefix)
+	return err
+}
+
+func runBundleExport(args []string, stdout io.Writer) error {
+	var endpoint, version, dir, providers, keyFile, output string
+
+	fs := flag.NewFlagSet("bundle export", flag.ContinueOnError)
+	fs.StringVar(&endpoint, "endpoint", images.APIEndpoint, "Endpoint of the info service, used when --dir is not given")
+	fs.StringVar(&version, "api-version", images.APIVersion, "Version of the info service API")
+	fs.StringVar(&dir, "dir", "", "Snapshot directory to bundle, like the current copy of a mirror")
+	fs.StringVar(&providers, "providers", "", "Comma separated list of the cloud frameworks to bundle, all when empty")
+	fs.StringVar(&keyFile, "key", "", "PEM encoded ed25519 private key signing the bundle (required)")
+	fs.StringVar(&output, "output", "snapshot.tar.gz", "Path of the bundle")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if keyFile == "" {
+		return fmt.Errorf("the --key flag is required")
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := bundle.ParsePrivateKey(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", keyFile, err)
+	}
+
+	if dir == "" {
+		// take a fresh snapshot of the info service
+		tmp, err := os.MkdirTemp("", "susepubliccloud-bundle-")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmp); err != nil {
+				log.Printf("failed to remove %s: %v", tmp, err)
+			}
+		}()
+
+		m := mirror.New(images.NewHTTPSource(endpoint), tmp)
+		m.APIVersion = version
+		for _, p := range strings.Split(providers, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				m.Providers = append(m.Providers, p)
+			}
+		}
+		if err := m.Sync(); err != nil {
+			return err
+		}
+		dir = m.CurrentDir()
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Write(f, dir, key); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Written %s signed by key %s\n",
+		output, bundle.KeyID(key.Public().(ed25519.PublicKey)))
+	return err
+}
+
+func runBundleVerify(args []string, stdout io.Writer) error {
+	var keyFiles string
+
+	fs := flag.NewFlagSet("bundle verify", flag.ContinueOnError)
+	fs.StringVar(&keyFiles, "keys", "", "Comma separated list of PEM encoded trusted public keys (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if keyFiles == "" || fs.NArg() != 1 {
+		return fmt.Errorf("expected the --keys flag and the path of the bundle")
+	}
+
+	trusted := make([]ed25519.PublicKey, 0)
+	for _, keyFile := range strings.Split(keyFiles, ",") {
+		data, err := os.ReadFile(strings.TrimSpace(keyFile))
+		if err != nil {
+			return err
+		}
+		key, err := bundle.ParsePublicKey(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", keyFile, err)
+		}
+		trusted = append(trusted, key)
+	}
+
+	b, err := bundle.Open(fs.Arg(0), trusted)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s: %d documents created at %s, signed by key %s\n",
+		fs.Arg(0), len(b.Manifest.Files), b.Manifest.CreatedAt, b.KeyID)
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+)
+
+func runServeFake(args []string, stdout io.Writer) error {
+	var fixtures, listen, statusPrefix, malformedPrefix string
+	var latency time.Duration
+	var status int
+
+	fs := flag.NewFlagSet("serve-fake", flag.ContinueOnError)
+	fs.StringVar(&fixtures, "fixtures", "", "Fixture directory holding one sub-directory per cloud framework (required)")
+	fs.StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	fs.DurationVar(&latency, "latency", 0, "Latency added to all the replies")
+	fs.IntVar(&status, "status", 0, "HTTP status code returned instead of the regular replies")
+	fs.StringVar(&statusPrefix, "status-prefix", "/", "Only the paths with this prefix are replied with --status")
+	fs.StringVar(&malformedPrefix, "malformed-prefix", "", "The paths with this prefix are replied with a malformed payload")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fixtures == "" {
+		return fmt.Errorf("the --fixtures flag is required")
+	}
+
+	catalog, err := fake.LoadDir(fixtures)
+	if err != nil {
+		return err
+	}
+
+	handler := fake.NewHandler(catalog)
+	if latency > 0 {
+		handler.AddHook(fake.WithLatency(latency))
+	}
+	if status != 0 {
+		handler.AddHook(fake.WithStatus(statusPrefix, status))
+	}
+	if malformedPrefix != "" {
+		handler.AddHook(fake.WithMalformedPayload(malformedPrefix))
+	}
+
+	if _, err := fmt.Fprintf(stdout, "Serving fake info service on http://%s\n", listen); err != nil {
+		return err
+	}
+	log.Printf("serving fixtures from %s", fixtures)
+
+	return http.ListenAndServe(listen, handler)
+}
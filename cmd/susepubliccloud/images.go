@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+var imageColumns = []string{
+	"name",
+	"state",
+	"replacementname",
+	"replacementid",
+	"publishedon",
+	"deprecatedon",
+	"region",
+	"id",
+	"deletedon",
+}
+
+var explanationColumns = []string{
+	"name",
+	"id",
+	"state",
+	"region",
+	"selected",
+	"criterion",
+	"reason",
+}
+
+func runImages(args []string, stdout io.Writer) error {
+	var api apiFlags
+	var params images.SearchParams
+	var explain bool
+
+	fs := newFlagSet("images", &api)
+	fs.StringVar(&params.Cloud, "cloud", "", "Name of the cloud framework (required)")
+	fs.StringVar(&params.Region, "region", "", "Region of the cloud framework, all the regions when empty")
+	fs.StringVar(&params.State, "state", "active", "State of the images")
+	fs.StringVar(&params.NameRegex, "name-regex", "", "Regular expression matched against the image names")
+	fs.StringVar(&params.Filter, "filter", "", "Filter expression evaluated against each image")
+	fs.BoolVar(&params.SortAscending, "sort-ascending", false, "Sort by ascending publication time")
+	fs.BoolVar(&explain, "explain", false, "List all the images, reporting the criterion which excluded each of them")
+	if err := parseFlags(fs, &api, args); err != nil {
+		return err
+	}
+
+	if params.Cloud == "" {
+		return fmt.Errorf("the --cloud flag is required")
+	}
+	params.APIEndpoint = api.endpoint
+	params.APIVersion = api.version
+	params.Source = api.source()
+
+	if explain {
+		return explainImages(params, stdout, api.output)
+	}
+
+	var found []images.Image
+	var err error
+	if params.Region == "" {
+		// search across all the regions using the region-less listing
+		catalog := images.NewCatalog(params.APIEndpoint, params.APIVersion, params.Cloud)
+		catalog.Source = params.Source
+		found, err = catalog.Search(params)
+	} else {
+		found, err = images.GetImages(params)
+	}
+	if err != nil {
+		return err
+	}
+
+	res := result{kind: "images", columns: imageColumns}
+	for _, image := range found {
+		res.rows = append(res.rows, []string{
+			image.Name,
+			image.State,
+			image.ReplacementName,
+			image.ReplacementID,
+			image.PublishedOn,
+			image.DeprecatedOn,
+			image.Region,
+			image.ID,
+			image.DeletedOn,
+		})
+	}
+
+	return res.write(stdout, api.output)
+}
+
+func explainImages(params images.SearchParams, stdout io.Writer, output string) error {
+	var explanations []images.Explanation
+	var err error
+	if params.Region == "" {
+		catalog := images.NewCatalog(params.APIEndpoint, params.APIVersion, params.Cloud)
+		catalog.Source = params.Source
+		explanations, err = catalog.Explain(params)
+	} else {
+		explanations, err = images.ExplainImages(params)
+	}
+	if err != nil {
+		return err
+	}
+
+	res := result{kind: "images", columns: explanationColumns}
+	for _, e := range explanations {
+		res.rows = append(res.rows, []string{
+			e.Image.Name,
+			e.Image.ID,
+			e.Image.State,
+			e.Image.Region,
+			strconv.FormatBool(e.Selected),
+			e.Criterion,
+			e.Reason,
+		})
+	}
+
+	return res.write(stdout, output)
+}
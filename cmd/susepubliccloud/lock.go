@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+var lockChangeColumns = []string{
+	"hash",
+	"cloud",
+	"region",
+	"state",
+	"nameregex",
+	"filter",
+	"old",
+	"new",
+}
+
+// runRefreshLock resolves again the queries recorded inside of a lock file
+// and reports the ones whose result changed. The selection policy set by the
+// flags is applied to the images like the provider does, so that the
+// recorded images are the ones the provider would select.
+func runRefreshLock(args []string, stdout io.Writer) error {
+	var api apiFlags
+	var policyArgs policyFlags
+	var path string
+	var dryRun bool
+
+	fs := newFlagSet("refresh-lock", &api)
+	fs.StringVar(&path, "lock-file", "", "Path of the lock file (required)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Report the queries that would change without updating the lock file")
+	policyArgs.register(fs)
+	if err := parseFlags(fs, &api, args); err != nil {
+		return err
+	}
+
+	if path == "" {
+		return fmt.Errorf("the --lock-file flag is required")
+	}
+	policy, err := policyArgs.policy()
+	if err != nil {
+		return err
+	}
+
+	lockFile, err := images.LoadLockFile(path)
+	if err != nil {
+		return err
+	}
+
+	changes, err := lockFile.Refresh(func(q images.LockQuery) ([]string, error) {
+		params := q.SearchParams()
+		params.APIEndpoint = api.endpoint
+		params.APIVersion = api.version
+		params.Source = api.source()
+
+		found, err := images.GetImages(params)
+		if err != nil {
+			return nil, err
+		}
+		found, err = policy.Apply(found, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return images.ImageIDs(found), nil
+	}, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if !dryRun && len(changes) > 0 {
+		if err := lockFile.Save(); err != nil {
+			return err
+		}
+	}
+
+	res := result{kind: "changes", columns: lockChangeColumns}
+	for _, change := range changes {
+		res.rows = append(res.rows, []string{
+			change.Hash,
+			change.Query.Cloud,
+			change.Query.Region,
+			change.Query.State,
+			change.Query.NameRegex,
+			change.Query.Filter,
+			strings.Join(change.Old, " "),
+			strings.Join(change.New, " "),
+		})
+	}
+
+	return res.write(stdout, api.output)
+}
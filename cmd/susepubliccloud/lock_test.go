@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+)
+
+func TestRunRefreshLockPolicy(t *testing.T) {
+	catalog, err := fake.LoadDir("../../susepubliccloud/testdata")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	server := fake.NewServer(catalog)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "susepubliccloud.lock.json")
+	lockFile, err := images.LoadLockFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	lockFile.Set(images.NewLockQuery(images.SearchParams{
+		Cloud:     "amazon",
+		Region:    "eu-central-1",
+		State:     "active",
+		NameRegex: "suse-sles-15-sp1-.*-hvm-ssd",
+	}), []string{"ami-0"})
+	if err := lockFile.Save(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the images violating the policy are not recorded, like the provider
+	// drops them
+	var out bytes.Buffer
+	err = run([]string{"refresh-lock", "--endpoint", server.URL, "--lock-file", path, "--dry-run", "--output", "csv",
+		"--allowed-licenses", "byos", "--allowed-architectures", "arm64"}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(out.String(), ",ami-0,ami-08d7e80118e53e581\n") {
+		t.Fatalf("Unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = run([]string{"refresh-lock", "--endpoint", server.URL, "--lock-file", path,
+		"--allowed-name-regex", "^suse-sles-15-sp1-byos", "--policy-mode", "reject"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "images violating the selection policy") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// the lock file is left untouched
+	if lockFile, err = images.LoadLockFile(path); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if entries := lockFile.Entries(); len(entries) != 1 || strings.Join(entries[0].IDs, " ") != "ami-0" {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+
+	err = run([]string{"refresh-lock", "--lock-file", path, "--policy-mode", "warn"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "--policy-mode") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
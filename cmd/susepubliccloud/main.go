@@ -0,0 +1,108 @@
+// Command susepubliccloud queries the SUSE public cloud info service, the
+// same service used by the terraform provider, from the command line.
+//
+// Usage:
+//
+//	susepubliccloud <command> [flags]
+//
+// Run "susepubliccloud <command> -h" to get the flags of each command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// command is a subcommand of the command line client
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdout io.Writer) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"images", "List the images published by SUSE", runImages},
+		{"servers", "List the servers of the SUSE update infrastructure", runServers},
+		{"providers", "List the known cloud frameworks", runProviders},
+		{"regions", "List the regions of a cloud framework", runRegions},
+		{"refresh-lock", "Refresh the image queries recorded inside of a lock file", runRefreshLock},
+		{"mirror", "Mirror the info service and serve the local copy", runMirror},
+		{"bundle", "Export and verify signed snapshot bundles", runBundle},
+		{"serve-fake", "Serve a fake info service from a fixture directory", runServeFake},
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
+	}
+
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Usage: susepubliccloud <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// apiFlags holds the flags shared by all the commands querying the API
+type apiFlags struct {
+	endpoint string
+	version  string
+	format   string
+	output   string
+}
+
+// source returns the source of the documents of the info service
+func (api *apiFlags) source() images.Source {
+	return &images.HTTPSource{Endpoint: api.endpoint, Format: images.Format(api.format)}
+}
+
+func newFlagSet(name string, api *apiFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&api.endpoint, "endpoint", images.APIEndpoint, "Endpoint of the info service")
+	fs.StringVar(&api.version, "api-version", images.APIVersion, "Version of the info service API")
+	fs.StringVar(&api.format, "api-format", string(images.FormatJSON), "Format of the documents requested to the info service: json or xml")
+	fs.StringVar(&api.output, "output", "table", "Output format: table, json, pint, csv or yaml")
+
+	return fs
+}
+
+// parseFlags parses the flags and validates the output format
+func parseFlags(fs *flag.FlagSet, api *apiFlags, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if err := images.ValidateFormat(api.format); err != nil {
+		return err
+	}
+	return validateOutput(api.output)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/mirror"
+)
+
+func runMirror(args []string, stdout io.Writer) error {
+	var endpoint, version, dir, providers, listen string
+	var interval, maxAge time.Duration
+	var once bool
+
+	fs := flag.NewFlagSet("mirror", flag.ContinueOnError)
+	fs.StringVar(&endpoint, "endpoint", images.APIEndpoint, "Endpoint of the upstream info service")
+	fs.StringVar(&version, "api-version", images.APIVersion, "Version of the info service API")
+	fs.StringVar(&dir, "dir", "", "Directory holding the local copy (required)")
+	fs.StringVar(&providers, "providers", "", "Comma separated list of the cloud frameworks to mirror, all when empty")
+	fs.StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	fs.DurationVar(&interval, "interval", time.Hour, "Time between two synchronizations")
+	fs.DurationVar(&maxAge, "max-age", 0, "Age after which the copy is reported as stale, twice --interval by default")
+	fs.BoolVar(&once, "once", false, "Synchronize the local copy once and exit without serving it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if dir == "" {
+		return fmt.Errorf("the --dir flag is required")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("the --interval flag must be positive")
+	}
+	if maxAge == 0 {
+		maxAge = 2 * interval
+	}
+
+	m := mirror.New(images.NewHTTPSource(endpoint), dir)
+	m.APIVersion = version
+	m.MaxAge = maxAge
+	for _, p := range strings.Split(providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			m.Providers = append(m.Providers, p)
+		}
+	}
+
+	if once {
+		if err := m.Sync(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(stdout, "Mirrored %s into %s\n",
+			strings.Join(m.Status().Providers, ", "), m.CurrentDir())
+		return err
+	}
+
+	go m.Run(interval, nil)
+
+	if _, err := fmt.Fprintf(stdout, "Serving mirror of %s on http://%s\n", endpoint, listen); err != nil {
+		return err
+	}
+	log.Printf("storing the local copy inside of %s", dir)
+
+	return http.ListenAndServe(listen, m)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+var outputFormats = []string{"table", "json", "pint", "csv", "yaml"}
+
+func validateOutput(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid output format %q, valid formats are: %s",
+		format, strings.Join(outputFormats, ", "))
+}
+
+// result holds the tabular data printed by the commands. The columns are
+// named after the keys of the API replies.
+type result struct {
+	// kind is the name of the listed objects, like "images"
+	kind    string
+	columns []string
+	rows    [][]string
+}
+
+func (r result) write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return r.writeJSON(w)
+	case "pint":
+		return r.writePint(w)
+	case "csv":
+		return r.writeCSV(w)
+	case "yaml":
+		return r.writeYAML(w)
+	default:
+		return r.writeTable(w)
+	}
+}
+
+func (r result) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.columns, "\t"))); err != nil {
+		return err
+	}
+	for _, row := range r.rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (r result) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(r.rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// objects returns the rows as JSON objects, keeping the order of the columns
+func (r result) objects() ([]json.RawMessage, error) {
+	objects := make([]json.RawMessage, 0, len(r.rows))
+	for _, row := range r.rows {
+		var sb strings.Builder
+		sb.WriteString("{")
+		for i, column := range r.columns {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			key, err := json.Marshal(column)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(row[i])
+			if err != nil {
+				return nil, err
+			}
+			sb.Write(key)
+			sb.WriteString(":")
+			sb.Write(value)
+		}
+		sb.WriteString("}")
+		objects = append(objects, json.RawMessage(sb.String()))
+	}
+
+	return objects, nil
+}
+
+func (r result) writeJSON(w io.Writer) error {
+	objects, err := r.objects()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+// writePint writes the same JSON document produced by the
+// public-cloud-info-client (pint) tool, which wraps the list of objects
+// inside of a key named after their kind
+func (r result) writePint(w io.Writer) error {
+	objects, err := r.objects()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string][]json.RawMessage{r.kind: objects})
+}
+
+func (r result) writeYAML(w io.Writer) error {
+	if len(r.rows) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+
+	for _, row := range r.rows {
+		for i, column := range r.columns {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			// JSON strings are valid YAML double quoted scalars
+			value, err := json.Marshal(row[i])
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, column, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResultWrite(t *testing.T) {
+	res := result{
+		kind:    "regions",
+		columns: []string{"name", "ip"},
+		rows: [][]string{
+			{"eu-central-1", "1.2.3.4"},
+			{"East US 2", ""},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "NAME          IP\neu-central-1  1.2.3.4\nEast US 2     \n"},
+		{"csv", "name,ip\neu-central-1,1.2.3.4\nEast US 2,\n"},
+		{"yaml", "- name: \"eu-central-1\"\n  ip: \"1.2.3.4\"\n- name: \"East US 2\"\n  ip: \"\"\n"},
+		{"json", `[
+  {
+    "name": "eu-central-1",
+    "ip": "1.2.3.4"
+  },
+  {
+    "name": "East US 2",
+    "ip": ""
+  }
+]
+`},
+		{"pint", `{
+  "regions": [
+    {
+      "name": "eu-central-1",
+      "ip": "1.2.3.4"
+    },
+    {
+      "name": "East US 2",
+      "ip": ""
+    }
+  ]
+}
+`},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := res.write(&out, test.format); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("Unexpected %s output. Got:\n%s\nexpected:\n%s", test.format, out.String(), test.expected)
+		}
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	if err := validateOutput("xml"); err == nil {
+		t.Fatal("xml should not be a valid output format")
+	}
+}
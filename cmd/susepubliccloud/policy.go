@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// policyFlags holds the flags of the selection policy, named like the
+// arguments of the provider
+type policyFlags struct {
+	allowedLicenses      string
+	allowedArchitectures string
+	forbidStates         string
+	maxImageAgeDays      int
+	allowedNameRegex     string
+	mode                 string
+}
+
+func (p *policyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.allowedLicenses, "allowed-licenses", "", "Comma separated licenses allowed by the selection policy")
+	fs.StringVar(&p.allowedArchitectures, "allowed-architectures", "", "Comma separated architectures allowed by the selection policy")
+	fs.StringVar(&p.forbidStates, "forbid-states", "", "Comma separated image states forbidden by the selection policy")
+	fs.IntVar(&p.maxImageAgeDays, "max-image-age-days", 0, "Maximum age of the images allowed by the selection policy")
+	fs.StringVar(&p.allowedNameRegex, "allowed-name-regex", "", "Regular expression the names of the images must match")
+	fs.StringVar(&p.mode, "policy-mode", images.PolicyModeFilter, "Action taken on the images violating the selection policy: filter or reject")
+}
+
+// policy returns the selection policy set by the flags, nil when no rule is
+// set, like the provider does
+func (p *policyFlags) policy() (*images.Policy, error) {
+	if p.mode != images.PolicyModeFilter && p.mode != images.PolicyModeReject {
+		return nil, fmt.Errorf("the --policy-mode flag must be %s or %s", images.PolicyModeFilter, images.PolicyModeReject)
+	}
+	if p.maxImageAgeDays < 0 {
+		return nil, fmt.Errorf("the --max-image-age-days flag must not be negative")
+	}
+
+	policy := images.Policy{
+		AllowedLicenses:      splitFlag(p.allowedLicenses),
+		AllowedArchitectures: splitFlag(p.allowedArchitectures),
+		ForbidStates:         splitFlag(p.forbidStates),
+		MaxImageAgeDays:      p.maxImageAgeDays,
+		Mode:                 p.mode,
+	}
+	if p.allowedNameRegex != "" {
+		re, err := regexp.Compile(p.allowedNameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --allowed-name-regex: %v", err)
+		}
+		policy.AllowedNameRegex = re
+	}
+
+	if len(policy.AllowedLicenses) == 0 && len(policy.AllowedArchitectures) == 0 &&
+		len(policy.ForbidStates) == 0 && policy.MaxImageAgeDays == 0 && policy.AllowedNameRegex == nil {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+// splitFlag returns the non-empty values of a comma separated flag
+func splitFlag(v string) []string {
+	var res []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
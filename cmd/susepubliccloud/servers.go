@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+var serverColumns = []string{
+	"type",
+	"shape",
+	"name",
+	"ip",
+	"region",
+	"ipv6",
+}
+
+func runServers(args []string, stdout io.Writer) error {
+	var api apiFlags
+	var params images.ServerSearchParams
+
+	fs := newFlagSet("servers", &api)
+	fs.StringVar(&params.Cloud, "cloud", "", "Name of the cloud framework (required)")
+	fs.StringVar(&params.Region, "region", "", "Region of the cloud framework, all the regions when empty")
+	fs.StringVar(&params.Type, "type", "regionserver", "Type of the servers")
+	if err := parseFlags(fs, &api, args); err != nil {
+		return err
+	}
+
+	if params.Cloud == "" {
+		return fmt.Errorf("the --cloud flag is required")
+	}
+	params.APIEndpoint = api.endpoint
+	params.APIVersion = api.version
+	params.Source = api.source()
+
+	servers, err := images.GetServers(params)
+	if err != nil {
+		return err
+	}
+
+	res := result{kind: "servers", columns: serverColumns}
+	for _, server := range servers {
+		res.rows = append(res.rows, []string{
+			server.Type,
+			server.Shape,
+			server.Name,
+			server.IP,
+			server.Region,
+			server.IPv6,
+		})
+	}
+
+	return res.write(stdout, api.output)
+}
+
+func runProviders(args []string, stdout io.Writer) error {
+	var api apiFlags
+
+	fs := newFlagSet("providers", &api)
+	if err := parseFlags(fs, &api, args); err != nil {
+		return err
+	}
+
+	providers, err := images.GetProvidersFrom(api.source(), api.version)
+	if err != nil {
+		return err
+	}
+
+	return namesResult("providers", providers).write(stdout, api.output)
+}
+
+func runRegions(args []string, stdout io.Writer) error {
+	var api apiFlags
+	var cloud string
+
+	fs := newFlagSet("regions", &api)
+	fs.StringVar(&cloud, "cloud", "", "Name of the cloud framework (required)")
+	if err := parseFlags(fs, &api, args); err != nil {
+		return err
+	}
+
+	if cloud == "" {
+		return fmt.Errorf("the --cloud flag is required")
+	}
+
+	regions, err := images.GetRegionsFrom(api.source(), api.version, cloud)
+	if err != nil {
+		return err
+	}
+
+	return namesResult("regions", regions).write(stdout, api.output)
+}
+
+func namesResult(kind string, names []string) result {
+	res := result{kind: kind, columns: []string{"name"}}
+	for _, name := range names {
+		res.rows = append(res.rows, []string{name})
+	}
+
+	return res
+}
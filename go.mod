@@ -4,7 +4,11 @@ go 1.23.0
 
 toolchain go1.24.2
 
-require github.com/hashicorp/terraform-plugin-sdk v1.17.2
+require (
+	github.com/hashicorp/hcl/v2 v2.8.2
+	github.com/hashicorp/terraform-plugin-sdk v1.17.2
+	github.com/zclconf/go-cty v1.8.2
+)
 
 require (
 	cloud.google.com/go v0.110.0 // indirect
@@ -38,7 +42,6 @@ require (
 	github.com/hashicorp/go-safetemp v1.0.0 // indirect
 	github.com/hashicorp/go-uuid v1.0.1 // indirect
 	github.com/hashicorp/go-version v1.6.0 // indirect
-	github.com/hashicorp/hcl/v2 v2.8.2 // indirect
 	github.com/hashicorp/terraform-svchost v0.0.0-20200729002733-f050f53b9734 // indirect
 	github.com/hashicorp/yamux v0.0.0-20181012175058-2f1d1f20f75d // indirect
 	github.com/huandu/xstrings v1.3.2 // indirect
@@ -60,7 +63,6 @@ require (
 	github.com/ulikunitz/xz v0.5.14 // indirect
 	github.com/vmihailenco/msgpack/v4 v4.3.12 // indirect
 	github.com/vmihailenco/tagparser v0.1.1 // indirect
-	github.com/zclconf/go-cty v1.8.2 // indirect
 	github.com/zclconf/go-cty-yaml v1.0.2 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
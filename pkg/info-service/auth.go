@@ -0,0 +1,140 @@
+package images
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the secrets inside of logs and error messages
+const redacted = "<redacted>"
+
+// Auth holds the credentials used to access an instance of the info service
+// sitting behind authentication, like a private mirror.
+//
+// The String and GoString methods never print the secrets, hence an Auth
+// can be safely logged.
+type Auth struct {
+	// BearerToken is sent inside of the Authorization header
+	BearerToken string
+	// Username and Password are sent using HTTP basic authentication
+	Username string
+	Password string
+	// Headers are added to all the requests, their values are considered
+	// secrets
+	Headers map[string]string
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and
+	// key used for mutual TLS authentication
+	ClientCertFile string
+	ClientKeyFile  string
+	// CAFile is a PEM encoded bundle of certificate authorities trusted in
+	// addition to the system ones
+	CAFile string
+}
+
+// Validate reports the incompatible or incomplete settings
+func (a *Auth) Validate() error {
+	if a.BearerToken != "" && (a.Username != "" || a.Password != "") {
+		return fmt.Errorf("bearer token and basic authentication cannot be used together")
+	}
+	if a.Password != "" && a.Username == "" {
+		return fmt.Errorf("a username is required by basic authentication")
+	}
+	if (a.ClientCertFile == "") != (a.ClientKeyFile == "") {
+		return fmt.Errorf("both a client certificate and a client key are required by mutual TLS")
+	}
+
+	return nil
+}
+
+// Apply adds the credentials to the request
+func (a *Auth) Apply(req *http.Request) {
+	for name, value := range a.Headers {
+		req.Header.Set(name, value)
+	}
+
+	switch {
+	case a.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+a.BearerToken)
+	case a.Username != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+}
+
+// Client returns an HTTP client presenting the client certificate and
+// trusting the certificate authorities of the settings. Its requests time
+// out after DefaultHTTPTimeout.
+func (a *Auth) Client() (*http.Client, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if a.ClientCertFile == "" && a.CAFile == "" {
+		return &http.Client{Timeout: DefaultHTTPTimeout}, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if a.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(a.ClientCertFile, a.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while loading the client certificate %s: %v", a.ClientCertFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if a.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(a.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while loading the certificate authorities %s: %v", a.CAFile, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM encoded certificate found inside of %s", a.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport, Timeout: DefaultHTTPTimeout}, nil
+}
+
+// String describes the authentication methods without printing the secrets
+func (a Auth) String() string {
+	methods := make([]string, 0)
+	if a.BearerToken != "" {
+		methods = append(methods, "BearerToken:"+redacted)
+	}
+	if a.Username != "" {
+		methods = append(methods, fmt.Sprintf("Username:%s Password:%s", a.Username, redacted))
+	}
+	if len(a.Headers) > 0 {
+		names := make([]string, 0, len(a.Headers))
+		for name := range a.Headers {
+			names = append(names, name+":"+redacted)
+		}
+		sort.Strings(names)
+		methods = append(methods, fmt.Sprintf("Headers:map[%s]", strings.Join(names, " ")))
+	}
+	if a.ClientCertFile != "" {
+		methods = append(methods, fmt.Sprintf("ClientCertFile:%s ClientKeyFile:%s", a.ClientCertFile, a.ClientKeyFile))
+	}
+	if a.CAFile != "" {
+		methods = append(methods, "CAFile:"+a.CAFile)
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(methods, " "))
+}
+
+// GoString is like String, used by the %#v verb
+func (a Auth) GoString() string {
+	return "images.Auth" + a.String()
+}
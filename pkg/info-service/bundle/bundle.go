@@ -0,0 +1,272 @@
+// Package bundle implements signed snapshot bundles of the SUSE public cloud
+// info service.
+//
+// A bundle is a gzip compressed tarball holding the documents of the API,
+// using the layout of images.DirectorySource, together with a manifest
+// listing the SHA-256 checksum of each document. The manifest is signed
+// with an ed25519 key:
+//
+//	manifest.json   {"format": 1, "created_at": "...", "files": {"v1/providers.json": "<sha256>", ...}}
+//	manifest.sig    {"key_id": "<id>", "signature": "<base64>"}
+//	v1/...
+//
+// Bundles are opened with Open, which refuses the bundles whose signature is
+// not made by one of the trusted keys, or whose documents do not match the
+// checksums of the manifest. An opened Bundle is an images.Source.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// Format is the version of the bundle format written by this package
+const Format = 1
+
+// Names of the files holding the manifest and its signature
+const (
+	ManifestFile  = "manifest.json"
+	SignatureFile = "manifest.sig"
+)
+
+// Manifest lists the documents of a bundle
+type Manifest struct {
+	Format    int    `json:"format"`
+	CreatedAt string `json:"created_at"`
+	// Files maps the path of each document to its hex encoded SHA-256
+	// checksum
+	Files map[string]string `json:"files"`
+}
+
+// Signature is the ed25519 signature of the manifest
+type Signature struct {
+	// KeyID identifies the key used to sign the manifest, see KeyID
+	KeyID string `json:"key_id"`
+	// Signature is the base64 encoded signature of the manifest file
+	Signature string `json:"signature"`
+}
+
+// KeyID returns the identifier of a public key: the first 8 bytes of its
+// SHA-256 checksum, hex encoded
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Write creates a bundle holding all the files found inside of dir, which
+// must use the layout of images.DirectorySource, and signs it with key
+func Write(w io.Writer, dir string, key ed25519.PrivateKey) error {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile || rel == SignatureFile {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[rel] = data
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while reading %s: %v", dir, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no documents found inside of %s", dir)
+	}
+
+	manifest := Manifest{
+		Format:    Format,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Files:     make(map[string]string, len(files)),
+	}
+	for p, data := range files {
+		manifest.Files[p] = checksum(data)
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	signature, err := json.Marshal(Signature{
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifestData)),
+	})
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  time.Now(),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := add(ManifestFile, manifestData); err != nil {
+		return err
+	}
+	if err := add(SignatureFile, signature); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := add(p, files[p]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Bundle is a verified snapshot bundle
+type Bundle struct {
+	*images.ArchiveSource
+
+	// Manifest is the verified manifest of the bundle
+	Manifest Manifest
+	// KeyID identifies the trusted key that signed the bundle
+	KeyID string
+}
+
+// Open loads the bundle found at path and verifies it: the manifest must be
+// signed by one of the trusted keys and the documents must match the
+// checksums of the manifest. Documents not listed by the manifest are
+// rejected as well.
+func Open(path string, trusted []ed25519.PublicKey) (*Bundle, error) {
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no trusted keys provided to verify bundle %s", path)
+	}
+
+	src, err := images.NewArchiveSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestData, err := readAll(src, ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %v", path, err)
+	}
+	signatureData, err := readAll(src, SignatureFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %v", path, err)
+	}
+
+	var signature Signature
+	if err := json.Unmarshal(signatureData, &signature); err != nil {
+		return nil, fmt.Errorf("invalid signature of bundle %s: %v", path, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of bundle %s: %v", path, err)
+	}
+
+	keyID := ""
+	for _, key := range trusted {
+		if ed25519.Verify(key, manifestData, sig) {
+			keyID = KeyID(key)
+			break
+		}
+	}
+	if keyID == "" {
+		return nil, fmt.Errorf("the signature of bundle %s (key %s) is not made by a trusted key",
+			path, signature.KeyID)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of bundle %s: %v", path, err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("unsupported format %d of bundle %s, expected %d",
+			manifest.Format, path, Format)
+	}
+
+	found := make(map[string]bool)
+	for _, p := range src.Paths() {
+		if p == ManifestFile || p == SignatureFile {
+			continue
+		}
+		expected, ok := manifest.Files[p]
+		if !ok {
+			return nil, fmt.Errorf("bundle %s: %s is not listed by the manifest", path, p)
+		}
+		data, err := readAll(src, p)
+		if err != nil {
+			return nil, err
+		}
+		if actual := checksum(data); actual != expected {
+			return nil, fmt.Errorf("bundle %s: checksum mismatch of %s, got %s, expected %s",
+				path, p, actual, expected)
+		}
+		found[p] = true
+	}
+	for p := range manifest.Files {
+		if !found[p] {
+			return nil, fmt.Errorf("bundle %s: %s is listed by the manifest but missing", path, p)
+		}
+	}
+
+	return &Bundle{ArchiveSource: src, Manifest: manifest, KeyID: keyID}, nil
+}
+
+func (b *Bundle) String() string {
+	return fmt.Sprintf("bundle %s", strings.TrimPrefix(b.ArchiveSource.String(), "archive "))
+}
+
+func readAll(src images.Source, p string) ([]byte, error) {
+	body, err := src.Open(p, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	return io.ReadAll(body)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,268 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+func generateKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pubPEM, privPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	pub, err := ParsePublicKey(pubPEM)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	priv, err := ParsePrivateKey(privPEM)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return pub, priv
+}
+
+// writeBundle writes a bundle of a snapshot holding the active images of
+// eu-central-1
+func writeBundle(t *testing.T, key ed25519.PrivateKey) string {
+	dir := t.TempDir()
+	active, err := os.ReadFile("../testdata/active.json")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	p := filepath.Join(dir, "v1", "amazon", "eu-central-1", "images", "active.json")
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := os.WriteFile(p, active, 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, dir, key); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return name
+}
+
+// rewriteBundle rewrites the files of a bundle using edit, files are
+// dropped when edit returns nil
+func rewriteBundle(t *testing.T, name string, edit func(name string, data []byte) []byte, extra map[string][]byte) {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	files := map[string][]byte{}
+	order := []string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if data = edit(hdr.Name, data); data != nil {
+			files[hdr.Name] = data
+			order = append(order, hdr.Name)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for n, data := range extra {
+		files[n] = data
+		order = append(order, n)
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, n := range order {
+		hdr := &tar.Header{Name: n, Mode: 0644, Size: int64(len(files[n])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := tw.Write(files[n]); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestOpenVerifiedBundle(t *testing.T) {
+	other, _ := generateKey(t)
+	pub, priv := generateKey(t)
+	name := writeBundle(t, priv)
+
+	b, err := Open(name, []ed25519.PublicKey{other, pub})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if b.KeyID != KeyID(pub) {
+		t.Fatalf("Unexpected signing key. Got %s, expected %s", b.KeyID, KeyID(pub))
+	}
+	if len(b.Manifest.Files) != 1 {
+		t.Fatalf("Unexpected number of files. Got %d, expected %d", len(b.Manifest.Files), 1)
+	}
+
+	found, err := images.GetImages(images.SearchParams{
+		Source:    b,
+		Cloud:     "amazon",
+		Region:    "eu-central-1",
+		State:     "active",
+		NameRegex: "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "ami-0f9515259be7cd031" {
+		t.Fatalf("Unexpected images %+v", found)
+	}
+}
+
+func TestOpenRejectsBundles(t *testing.T) {
+	untrusted, _ := generateKey(t)
+	pub, priv := generateKey(t)
+	trusted := []ed25519.PublicKey{pub}
+	const document = "v1/amazon/eu-central-1/images/active.json"
+
+	tests := []struct {
+		name     string
+		trusted  []ed25519.PublicKey
+		edit     func(name string, data []byte) []byte
+		extra    map[string][]byte
+		expected string
+	}{
+		{
+			name:     "untrusted_key",
+			trusted:  []ed25519.PublicKey{untrusted},
+			expected: "is not made by a trusted key",
+		},
+		{
+			name:     "no_keys",
+			expected: "no trusted keys",
+		},
+		{
+			name:    "tampered_document",
+			trusted: trusted,
+			edit: func(name string, data []byte) []byte {
+				if name == document {
+					return bytes.Replace(data, []byte("ami-0f9515259be7cd031"), []byte("ami-0badc0ffee0000000"), 1)
+				}
+				return data
+			},
+			expected: "checksum mismatch of " + document,
+		},
+		{
+			name:    "tampered_manifest",
+			trusted: trusted,
+			edit: func(name string, data []byte) []byte {
+				if name == ManifestFile {
+					return bytes.Replace(data, []byte(`"format": 1`), []byte(`"format":  1`), 1)
+				}
+				return data
+			},
+			expected: "is not made by a trusted key",
+		},
+		{
+			name:     "extra_document",
+			trusted:  trusted,
+			extra:    map[string][]byte{"v1/providers.json": []byte(`{"providers": []}`)},
+			expected: "v1/providers.json is not listed by the manifest",
+		},
+		{
+			name:    "missing_document",
+			trusted: trusted,
+			edit: func(name string, data []byte) []byte {
+				if name == document {
+					return nil
+				}
+				return data
+			},
+			expected: "is listed by the manifest but missing",
+		},
+		{
+			name:    "missing_signature",
+			trusted: trusted,
+			edit: func(name string, data []byte) []byte {
+				if name == SignatureFile {
+					return nil
+				}
+				return data
+			},
+			expected: "manifest.sig not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := writeBundle(t, priv)
+			if test.edit != nil || test.extra != nil {
+				edit := test.edit
+				if edit == nil {
+					edit = func(_ string, data []byte) []byte { return data }
+				}
+				rewriteBundle(t, name, edit, test.extra)
+			}
+
+			_, err := Open(name, test.trusted)
+			if err == nil {
+				t.Fatalf("The bundle should have been refused")
+			}
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("Unexpected error. Got %q, expected it to contain %q", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	pubPEM, privPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err := ParsePublicKey(privPEM); err == nil {
+		t.Errorf("A private key should not be parsed as a public key")
+	}
+	if _, err := ParsePrivateKey(pubPEM); err == nil {
+		t.Errorf("A public key should not be parsed as a private key")
+	}
+	if _, err := ParsePublicKey([]byte("garbage")); err == nil {
+		t.Errorf("Invalid keys should be refused")
+	}
+}
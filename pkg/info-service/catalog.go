@@ -0,0 +1,302 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCatalogCheckInterval is the minimum amount of time between two
+// checks of the upstream data version done by a Catalog
+const DefaultCatalogCheckInterval = 5 * time.Minute
+
+// Catalog is an in-memory copy of all the images published by SUSE on a
+// cloud framework.
+//
+// The whole catalog is downloaded at once using the region-less listing of
+// the API. The images are then indexed by id, name, region, state and product
+// so that searches do not require any additional HTTP request. The catalog is
+// reloaded whenever the data version reported by the API changes.
+type Catalog struct {
+	APIEndpoint string
+	APIVersion  string
+	Cloud       string
+	// Source provides the documents of the API, when nil they are fetched
+	// from APIEndpoint
+	Source Source
+
+	// CheckInterval is the minimum amount of time between two checks of
+	// the upstream data version. Defaults to DefaultCatalogCheckInterval.
+	CheckInterval time.Duration
+
+	mu        sync.RWMutex
+	loaded    bool
+	version   string
+	checkedAt time.Time
+	images    []Image
+	byID      map[string][]int
+	byName    map[string][]int
+	byRegion  map[string][]int
+	byState   map[string][]int
+	byProduct map[string][]int
+}
+
+// Internally used to parse the data version reply of the
+// SUSE public cloud info service API
+type dataVersionReply struct {
+	Version json.RawMessage `json:"version" xml:"-"`
+	// XMLVersion is the version found inside of the XML documents, like
+	// <dataversion version="20190624"/>
+	XMLVersion string `json:"-" xml:"version,attr"`
+}
+
+// NewCatalog returns an empty catalog of the images published on the given
+// cloud framework. The images are loaded on first use.
+func NewCatalog(endpoint, version, cloud string) *Catalog {
+	return &Catalog{
+		APIEndpoint:   endpoint,
+		APIVersion:    version,
+		Cloud:         cloud,
+		CheckInterval: DefaultCatalogCheckInterval,
+	}
+}
+
+// Refresh checks the upstream data version and reloads the whole catalog
+// when it changed since the last load
+func (c *Catalog) Refresh() error {
+	version, err := c.fetchDataVersion()
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	upToDate := c.loaded && version == c.version
+	c.mu.RUnlock()
+
+	if !upToDate {
+		return c.load(version)
+	}
+
+	c.mu.Lock()
+	c.checkedAt = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Version returns the upstream data version of the loaded images
+func (c *Catalog) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.version
+}
+
+// Search returns the images of the catalog that match the search criteria
+// provided by the user. The Cloud, APIEndpoint, APIVersion and Source fields
+// of the search parameters are ignored. Unlike GetImages, an empty Region matches
+// the images of all the regions and an empty State matches all the states.
+func (c *Catalog) Search(params SearchParams) ([]Image, error) {
+	if params.State != "" {
+		if err := ValidateState(params.State); err != nil {
+			return []Image{}, err
+		}
+	}
+
+	if err := c.ensureFresh(); err != nil {
+		return []Image{}, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var candidates []int
+	switch {
+	case params.Region != "" && params.State != "":
+		candidates = intersect(c.byRegion[params.Region], c.byState[params.State])
+	case params.Region != "":
+		candidates = c.byRegion[params.Region]
+	case params.State != "":
+		candidates = c.byState[params.State]
+	default:
+		candidates = make([]int, len(c.images))
+		for i := range c.images {
+			candidates[i] = i
+		}
+	}
+
+	return filterImages(c.collect(candidates), params)
+}
+
+// Explain returns the explanation of every image of the catalog found in
+// the region of the search criteria, see ExplainImages. An empty Region
+// explains the images of all the regions.
+func (c *Catalog) Explain(params SearchParams) ([]Explanation, error) {
+	if params.State != "" {
+		if err := ValidateState(params.State); err != nil {
+			return []Explanation{}, err
+		}
+	}
+
+	if err := c.ensureFresh(); err != nil {
+		return []Explanation{}, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	candidates := c.byRegion[params.Region]
+	if params.Region == "" {
+		candidates = make([]int, len(c.images))
+		for i := range c.images {
+			candidates[i] = i
+		}
+	}
+
+	return explainImages(c.collect(candidates), params)
+}
+
+// LookupID returns the images with the given id. Images of cloud frameworks
+// like Google Compute Engine are global, hence the same id can be reported
+// by more than one region.
+func (c *Catalog) LookupID(id string) ([]Image, error) {
+	return c.lookup(func() []int { return c.byID[id] })
+}
+
+// LookupName returns the images with the given name
+func (c *Catalog) LookupName(name string) ([]Image, error) {
+	return c.lookup(func() []int { return c.byName[name] })
+}
+
+// LookupProduct returns the images of the given product, as returned by
+// ParseName. For example "sles" or "manager".
+func (c *Catalog) LookupProduct(product string) ([]Image, error) {
+	return c.lookup(func() []int { return c.byProduct[strings.ToLower(product)] })
+}
+
+// Regions returns the sorted list of regions found inside of the catalog
+func (c *Catalog) Regions() ([]string, error) {
+	if err := c.ensureFresh(); err != nil {
+		return []string{}, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	regions := make([]string, 0, len(c.byRegion))
+	for region := range c.byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	return regions, nil
+}
+
+func (c *Catalog) lookup(index func() []int) ([]Image, error) {
+	if err := c.ensureFresh(); err != nil {
+		return []Image{}, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collect(index()), nil
+}
+
+// collect returns a copy of the images found at the given positions, the
+// caller must hold the lock
+func (c *Catalog) collect(positions []int) []Image {
+	images := make([]Image, 0, len(positions))
+	for _, pos := range positions {
+		images = append(images, c.images[pos])
+	}
+
+	return images
+}
+
+// ensureFresh loads the catalog on first use and refreshes it once
+// CheckInterval elapsed since the last check
+func (c *Catalog) ensureFresh() error {
+	c.mu.RLock()
+	fresh := c.loaded && time.Since(c.checkedAt) < c.CheckInterval
+	c.mu.RUnlock()
+
+	if fresh {
+		return nil
+	}
+
+	return c.Refresh()
+}
+
+func (c *Catalog) fetchDataVersion() (string, error) {
+	var reply dataVersionReply
+	p := apiPath(c.APIVersion, c.Cloud, "dataversion")
+	src := sourceFor(c.Source, c.APIEndpoint)
+	if err := getDocument(src, p, "category=images", &reply); err != nil {
+		return "", classifyNotFound(err, src, c.APIVersion, c.Cloud, "")
+	}
+
+	if reply.XMLVersion != "" {
+		return reply.XMLVersion, nil
+	}
+	return strings.Trim(string(reply.Version), `"`), nil
+}
+
+func (c *Catalog) load(version string) error {
+	var reply imagesReply
+	p := apiPath(c.APIVersion, c.Cloud, "images.json")
+	if err := getDocument(sourceFor(c.Source, c.APIEndpoint), p, "", &reply); err != nil {
+		return fmt.Errorf("error while loading the %s catalog: %w", c.Cloud, err)
+	}
+
+	byID := make(map[string][]int)
+	byName := make(map[string][]int)
+	byRegion := make(map[string][]int)
+	byState := make(map[string][]int)
+	byProduct := make(map[string][]int)
+	for pos, image := range reply.Images {
+		byID[image.ID] = append(byID[image.ID], pos)
+		byName[image.Name] = append(byName[image.Name], pos)
+		byRegion[image.Region] = append(byRegion[image.Region], pos)
+		byState[image.State] = append(byState[image.State], pos)
+		product := ParseName(image.Name).Product
+		byProduct[product] = append(byProduct[product], pos)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.images = reply.Images
+	c.byID = byID
+	c.byName = byName
+	c.byRegion = byRegion
+	c.byState = byState
+	c.byProduct = byProduct
+	c.version = version
+	c.loaded = true
+	c.checkedAt = time.Now()
+
+	return nil
+}
+
+// intersect returns the positions found in both the sorted lists
+func intersect(a, b []int) []int {
+	res := make([]int, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return res
+}
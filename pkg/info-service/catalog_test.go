@@ -0,0 +1,141 @@
+package images
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// newCatalogServer returns a fake http server serving the region-less
+// listing of the images together with the given data version. The number
+// of catalog downloads is stored into loads.
+func newCatalogServer(t *testing.T, version *string, loads *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/v1/amazon/dataversion?category=images":
+			if _, err := fmt.Fprintf(w, `{"version": %s}`, *version); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		case "/v1/amazon/images.json":
+			*loads++
+			file, err := os.Open("testdata/active.json")
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			defer func() {
+				if err := file.Close(); err != nil {
+					t.Errorf("failed to close file: %v", err)
+				}
+			}()
+
+			if _, err := io.Copy(w, file); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		default:
+			t.Fatalf("Unexpected request %s", r.RequestURI)
+		}
+	}))
+}
+
+func TestCatalogSearch(t *testing.T) {
+	version := "1.0"
+	loads := 0
+	ts := newCatalogServer(t, &version, &loads)
+	defer ts.Close()
+
+	catalog := NewCatalog(ts.URL, "v1", "amazon")
+
+	images, err := catalog.Search(SearchParams{
+		Region:        "eu-central-1",
+		State:         "active",
+		SortAscending: true,
+		NameRegex:     "suse-sles-.*-sapcal.*-hvm-ssd-x86_64",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedIDs := []string{
+		"ami-082bfb28e7de47e17",
+		"ami-057b6b1654d10ff7b",
+		"ami-07dd6bca2aa25c67d",
+	}
+	if len(images) != len(expectedIDs) {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(images), len(expectedIDs))
+	}
+	for pos, image := range images {
+		if image.ID != expectedIDs[pos] {
+			t.Fatalf("Sorting error for image at position %d", pos)
+		}
+	}
+
+	images, err = catalog.Search(SearchParams{Region: "us-east-1", State: "active"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(images) != 0 {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(images), 0)
+	}
+
+	if loads != 1 {
+		t.Fatalf("The catalog should have been loaded once, got %d loads", loads)
+	}
+}
+
+func TestCatalogLookup(t *testing.T) {
+	version := "1.0"
+	loads := 0
+	ts := newCatalogServer(t, &version, &loads)
+	defer ts.Close()
+
+	catalog := NewCatalog(ts.URL, "v1", "amazon")
+
+	images, err := catalog.LookupID("ami-0f9515259be7cd031")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(images) != 1 || images[0].Name != "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64" {
+		t.Fatalf("Unexpected lookup result %+v", images)
+	}
+
+	images, err = catalog.LookupProduct("manager")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(images) != 4 {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(images), 4)
+	}
+}
+
+func TestCatalogRefresh(t *testing.T) {
+	version := "1.0"
+	loads := 0
+	ts := newCatalogServer(t, &version, &loads)
+	defer ts.Close()
+
+	catalog := NewCatalog(ts.URL, "v1", "amazon")
+
+	if err := catalog.Refresh(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := catalog.Refresh(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if loads != 1 {
+		t.Fatalf("The catalog should not be reloaded when the version does not change, got %d loads", loads)
+	}
+
+	version = `"1.1"`
+	if err := catalog.Refresh(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if loads != 2 {
+		t.Fatalf("The catalog should be reloaded when the version changes, got %d loads", loads)
+	}
+	if catalog.Version() != "1.1" {
+		t.Fatalf("Unexpected catalog version. Got %s, expected %s", catalog.Version(), "1.1")
+	}
+}
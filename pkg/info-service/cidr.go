@@ -0,0 +1,140 @@
+package images
+
+import (
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"sort"
+)
+
+// ServerPrefixes returns the smallest sets of IPv4 and IPv6 prefixes covering
+// the addresses of the servers, see AggregatePrefixes. The servers without
+// an IPv6 address are skipped from the IPv6 set.
+func ServerPrefixes(servers []Server) (ipv4 []netip.Prefix, ipv6 []netip.Prefix, err error) {
+	ipv4 = make([]netip.Prefix, 0)
+	ipv6 = make([]netip.Prefix, 0)
+	for _, server := range servers {
+		for _, ip := range []string{server.IP, server.IPv6} {
+			if ip == "" {
+				continue
+			}
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid address of the %s server of %s: %w", server.Type, server.Region, err)
+			}
+			addr = addr.Unmap().WithZone("")
+			if addr.Is4() {
+				ipv4 = append(ipv4, netip.PrefixFrom(addr, addr.BitLen()))
+			} else {
+				ipv6 = append(ipv6, netip.PrefixFrom(addr, addr.BitLen()))
+			}
+		}
+	}
+
+	return AggregatePrefixes(ipv4), AggregatePrefixes(ipv6), nil
+}
+
+// AggregatePrefixes returns the smallest set of prefixes covering exactly the
+// addresses of the given prefixes: the duplicated and the nested prefixes
+// are dropped and the adjacent ones are merged, like 10.0.0.0/25 and
+// 10.0.0.128/25 into 10.0.0.0/24. The prefixes are sorted by address, the
+// IPv4 ones first.
+func AggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		if p.IsValid() {
+			sorted = append(sorted, p.Masked())
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Addr().Compare(sorted[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Bits() < sorted[j].Bits()
+	})
+
+	aggregated := make([]netip.Prefix, 0, len(sorted))
+	for _, p := range sorted {
+		if n := len(aggregated); n > 0 && aggregated[n-1].Overlaps(p) {
+			// sorted by address, the previous prefix is the larger one
+			continue
+		}
+		aggregated = append(aggregated, p)
+		// the merged prefix can in turn be merged with the previous one
+		for n := len(aggregated); n >= 2 && siblings(aggregated[n-2], aggregated[n-1]); n-- {
+			aggregated = append(aggregated[:n-2], parent(aggregated[n-2]))
+		}
+	}
+
+	return aggregated
+}
+
+// SummarizePrefixes reduces the prefixes to at most max entries, merging the
+// nearest ones into their common supernet until they fit. Unlike
+// AggregatePrefixes the result can cover more addresses than the given
+// prefixes, though the supernets are never shorter than minIPv4Bits, for
+// the IPv4 prefixes, and minIPv6Bits, for the IPv6 ones: an error is
+// returned when the prefixes cannot fit otherwise. The prefixes are
+// returned aggregated when they already fit, or when max is not positive.
+// The IPv4 and the IPv6 prefixes are never merged together.
+func SummarizePrefixes(prefixes []netip.Prefix, max, minIPv4Bits, minIPv6Bits int) ([]netip.Prefix, error) {
+	summarized := AggregatePrefixes(prefixes)
+	for max > 0 && len(summarized) > max {
+		// the supernet of the adjacent prefixes with the longest common
+		// prefix adds the fewest addresses
+		best := netip.Prefix{}
+		for i := 0; i+1 < len(summarized); i++ {
+			a, b := summarized[i], summarized[i+1]
+			if a.Addr().BitLen() != b.Addr().BitLen() {
+				continue
+			}
+			n := commonBits(a.Addr(), b.Addr())
+			if n > a.Bits() {
+				n = a.Bits()
+			}
+			if n > b.Bits() {
+				n = b.Bits()
+			}
+			min := minIPv4Bits
+			if a.Addr().Is6() {
+				min = minIPv6Bits
+			}
+			if n < min {
+				continue
+			}
+			if !best.IsValid() || n > best.Bits() {
+				best = netip.PrefixFrom(a.Addr(), n).Masked()
+			}
+		}
+		if !best.IsValid() {
+			return nil, fmt.Errorf("%d prefixes cannot be summarized into %d without IPv4 prefixes shorter than /%d "+
+				"or IPv6 prefixes shorter than /%d", len(summarized), max, minIPv4Bits, minIPv6Bits)
+		}
+		summarized = AggregatePrefixes(append(summarized, best))
+	}
+
+	return summarized, nil
+}
+
+// siblings tells whether the prefixes are the two halves of the same parent
+// prefix
+func siblings(a, b netip.Prefix) bool {
+	return a.Bits() == b.Bits() && a.Bits() > 0 && a != b && parent(a) == parent(b)
+}
+
+// parent returns the prefix one bit shorter than p
+func parent(p netip.Prefix) netip.Prefix {
+	return netip.PrefixFrom(p.Addr(), p.Bits()-1).Masked()
+}
+
+// commonBits returns the length of the common prefix of the addresses of
+// the same family
+func commonBits(a, b netip.Addr) int {
+	x, y := a.AsSlice(), b.AsSlice()
+	for i := range x {
+		if d := x[i] ^ y[i]; d != 0 {
+			return i*8 + bits.LeadingZeros8(d)
+		}
+	}
+	return len(x) * 8
+}
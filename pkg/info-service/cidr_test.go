@@ -0,0 +1,114 @@
+package images
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func parsePrefixes(t *testing.T, s ...string) []netip.Prefix {
+	t.Helper()
+	prefixes := make([]netip.Prefix, 0, len(s))
+	for _, p := range s {
+		prefixes = append(prefixes, netip.MustParsePrefix(p))
+	}
+	return prefixes
+}
+
+func formatPrefixes(prefixes []netip.Prefix) string {
+	s := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		s = append(s, p.String())
+	}
+	return strings.Join(s, " ")
+}
+
+func TestAggregatePrefixes(t *testing.T) {
+	for _, test := range []struct {
+		prefixes []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"10.0.0.1/32", "10.0.0.1/32"}, "10.0.0.1/32"},
+		{[]string{"10.0.0.3/32", "10.0.0.2/32"}, "10.0.0.2/31"},
+		{[]string{"10.0.0.1/32", "10.0.0.2/32"}, "10.0.0.1/32 10.0.0.2/32"},
+		{[]string{"10.0.0.0/25", "10.0.0.128/25", "10.0.1.0/24"}, "10.0.0.0/23"},
+		{[]string{"10.0.0.0/24", "10.0.0.7/32", "10.0.0.128/25"}, "10.0.0.0/24"},
+		{[]string{"10.0.0.4/32", "10.0.0.0/30", "10.0.0.5/32", "10.0.0.6/31"}, "10.0.0.0/29"},
+		{[]string{"2a05:d014:cea:a201::5/128", "2a05:d014:cea:a201::4/128", "52.28.243.25/32"},
+			"52.28.243.25/32 2a05:d014:cea:a201::4/127"},
+		{[]string{"10.1.2.3/16"}, "10.1.0.0/16"},
+	} {
+		got := formatPrefixes(AggregatePrefixes(parsePrefixes(t, test.prefixes...)))
+		if got != test.expected {
+			t.Fatalf("Unexpected prefixes for %v. Got %q, expected %q", test.prefixes, got, test.expected)
+		}
+	}
+}
+
+func TestSummarizePrefixes(t *testing.T) {
+	prefixes := parsePrefixes(t, "10.0.0.1/32", "10.0.0.6/32", "10.0.1.0/24", "192.168.0.1/32")
+	for _, test := range []struct {
+		max      int
+		min      int
+		expected string
+	}{
+		{0, 16, "10.0.0.1/32 10.0.0.6/32 10.0.1.0/24 192.168.0.1/32"},
+		{4, 16, "10.0.0.1/32 10.0.0.6/32 10.0.1.0/24 192.168.0.1/32"},
+		{3, 16, "10.0.0.0/29 10.0.1.0/24 192.168.0.1/32"},
+		{2, 16, "10.0.0.0/23 192.168.0.1/32"},
+		{1, 0, "0.0.0.0/0"},
+	} {
+		summarized, err := SummarizePrefixes(prefixes, test.max, test.min, 32)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := formatPrefixes(summarized); got != test.expected {
+			t.Fatalf("Unexpected prefixes for %d rules. Got %q, expected %q", test.max, got, test.expected)
+		}
+	}
+
+	// the prefixes don't fit without going below the minimum length
+	if got, err := SummarizePrefixes(prefixes, 1, 16, 32); err == nil {
+		t.Fatalf("Expected an error, got %s", formatPrefixes(got))
+	}
+	ipv6 := parsePrefixes(t, "2a05:d014:cea:a201::5/128", "2a05:d014:cea:a202::5/128", "2600:1f18::1/128")
+	if got, err := SummarizePrefixes(ipv6, 1, 16, 32); err == nil {
+		t.Fatalf("Expected an error, got %s", formatPrefixes(got))
+	}
+	summarized, err := SummarizePrefixes(ipv6, 2, 16, 32)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got, expected := formatPrefixes(summarized), "2600:1f18::1/128 2a05:d014:cea:a200::/62"; got != expected {
+		t.Fatalf("Unexpected prefixes. Got %q, expected %q", got, expected)
+	}
+
+	// the address families are never merged together
+	mixed := parsePrefixes(t, "10.0.0.1/32", "2a05:d014:cea:a201::5/128")
+	if got, err := SummarizePrefixes(mixed, 1, 0, 0); err == nil {
+		t.Fatalf("Expected an error, got %s", formatPrefixes(got))
+	}
+}
+
+func TestServerPrefixes(t *testing.T) {
+	ipv4, ipv6, err := ServerPrefixes([]Server{
+		{Type: "regionserver", IP: "18.156.115.8", IPv6: "2a05:d014:cea:a201::5", Region: "eu-central-1"},
+		{Type: "regionserver", IP: "18.156.115.9", IPv6: "2a05:d014:cea:a201::4", Region: "eu-central-1"},
+		{Type: "smt", IP: "3.124.39.111", Region: "eu-central-1"},
+		{Type: "smt", IP: "::ffff:3.124.39.111", Region: "eu-central-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got, expected := formatPrefixes(ipv4), "3.124.39.111/32 18.156.115.8/31"; got != expected {
+		t.Fatalf("Unexpected IPv4 prefixes. Got %q, expected %q", got, expected)
+	}
+	if got, expected := formatPrefixes(ipv6), "2a05:d014:cea:a201::4/127"; got != expected {
+		t.Fatalf("Unexpected IPv6 prefixes. Got %q, expected %q", got, expected)
+	}
+
+	if _, _, err := ServerPrefixes([]Server{{Type: "smt", IP: "3.124.39", Region: "eu-central-1"}}); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
@@ -0,0 +1,196 @@
+package images
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Cloud identifies a cloud framework covered by the info service, it is the
+// first element of the API paths, like "amazon" in
+// https://susepubliccloudinfo.suse.com/v1/amazon/images.json
+type Cloud string
+
+// The cloud frameworks known by the package
+const (
+	CloudAlibaba   Cloud = "alibaba"
+	CloudAmazon    Cloud = "amazon"
+	CloudGoogle    Cloud = "google"
+	CloudMicrosoft Cloud = "microsoft"
+	CloudOracle    Cloud = "oracle"
+)
+
+// RegionStyle describes how the regions of a cloud framework are named
+type RegionStyle string
+
+// The naming styles of the regions
+const (
+	// RegionStyleCode is used by the clouds naming their regions with
+	// lower case codes, like "eu-central-1"
+	RegionStyleCode RegionStyle = "code"
+	// RegionStyleDisplayName is used by the clouds naming their regions
+	// with human readable names, like "East US 2"
+	RegionStyleDisplayName RegionStyle = "display-name"
+)
+
+// ImageIDKind describes the identifiers of the images of a cloud framework
+type ImageIDKind string
+
+// The kinds of image identifiers
+const (
+	// ImageIDMachineImage identifies images with a cloud specific machine
+	// image identifier, like "ami-0352b14942c00b04b"
+	ImageIDMachineImage ImageIDKind = "machine-image"
+	// ImageIDName identifies images by their name
+	ImageIDName ImageIDKind = "name"
+	// ImageIDURN identifies images by their URN, like
+	// "SUSE:sles-15-sp1:gen1:2019.06.24"
+	ImageIDURN ImageIDKind = "urn"
+	// ImageIDOCID identifies images by their Oracle Cloud identifier
+	ImageIDOCID ImageIDKind = "ocid"
+)
+
+// CloudInfo holds the metadata of a cloud framework
+type CloudInfo struct {
+	Name Cloud
+	// DisplayName is the name of the cloud framework used by humans
+	DisplayName string
+	RegionStyle RegionStyle
+	// RegionExample is the name of one of the regions, shown inside of
+	// error messages
+	RegionExample string
+	ImageIDKind   ImageIDKind
+	// ExtraFields are the fields of the image documents specific to the
+	// cloud framework
+	ExtraFields []string
+	// RegionAliases maps alternative names of the regions, like the ones
+	// shown by the consoles, to the names used by the API. See
+	// CanonicalRegion.
+	RegionAliases map[string]string
+}
+
+var (
+	cloudsMu sync.RWMutex
+	clouds   = make(map[Cloud]CloudInfo)
+)
+
+func init() {
+	for _, info := range []CloudInfo{
+		{
+			Name:          CloudAlibaba,
+			DisplayName:   "Alibaba Cloud",
+			RegionStyle:   RegionStyleCode,
+			RegionExample: "cn-beijing",
+			ImageIDKind:   ImageIDMachineImage,
+			RegionAliases: map[string]string{
+				"China (Beijing)":     "cn-beijing",
+				"China (Hangzhou)":    "cn-hangzhou",
+				"China (Shanghai)":    "cn-shanghai",
+				"China (Hong Kong)":   "cn-hongkong",
+				"Germany (Frankfurt)": "eu-central-1",
+				"Singapore":           "ap-southeast-1",
+			},
+		},
+		{
+			Name:          CloudAmazon,
+			DisplayName:   "Amazon EC2",
+			RegionStyle:   RegionStyleCode,
+			RegionExample: "us-east-1",
+			ImageIDKind:   ImageIDMachineImage,
+			RegionAliases: map[string]string{
+				"US East (N. Virginia)":    "us-east-1",
+				"US East (Ohio)":           "us-east-2",
+				"US West (N. California)":  "us-west-1",
+				"US West (Oregon)":         "us-west-2",
+				"Europe (Frankfurt)":       "eu-central-1",
+				"Europe (Ireland)":         "eu-west-1",
+				"Europe (London)":          "eu-west-2",
+				"Europe (Paris)":           "eu-west-3",
+				"Europe (Stockholm)":       "eu-north-1",
+				"Asia Pacific (Tokyo)":     "ap-northeast-1",
+				"Asia Pacific (Singapore)": "ap-southeast-1",
+				"Asia Pacific (Sydney)":    "ap-southeast-2",
+			},
+		},
+		{
+			Name:          CloudGoogle,
+			DisplayName:   "Google Compute Engine",
+			RegionStyle:   RegionStyleCode,
+			RegionExample: "us-east1",
+			ImageIDKind:   ImageIDName,
+			ExtraFields:   []string{"project"},
+		},
+		{
+			Name:          CloudMicrosoft,
+			DisplayName:   "Microsoft Azure",
+			RegionStyle:   RegionStyleDisplayName,
+			RegionExample: "East US 2",
+			ImageIDKind:   ImageIDURN,
+			ExtraFields:   []string{"environment", "urn"},
+		},
+		{
+			Name:          CloudOracle,
+			DisplayName:   "Oracle Cloud Infrastructure",
+			RegionStyle:   RegionStyleCode,
+			RegionExample: "us-ashburn-1",
+			ImageIDKind:   ImageIDOCID,
+		},
+	} {
+		RegisterCloud(info)
+	}
+}
+
+// RegisterCloud adds a cloud framework to the known ones, replacing the
+// metadata of a cloud framework with the same name
+func RegisterCloud(info CloudInfo) {
+	cloudsMu.Lock()
+	defer cloudsMu.Unlock()
+
+	clouds[info.Name] = info
+}
+
+// LookupCloud returns the metadata of a known cloud framework
+func LookupCloud(name string) (CloudInfo, bool) {
+	cloudsMu.RLock()
+	defer cloudsMu.RUnlock()
+
+	info, ok := clouds[Cloud(name)]
+	return info, ok
+}
+
+// ParseCloud returns the known cloud framework with the given name, the
+// error matches ErrUnknownProvider
+func ParseCloud(name string) (Cloud, error) {
+	if _, ok := LookupCloud(name); !ok {
+		return "", fmt.Errorf("%w %q, expected one of %s",
+			ErrUnknownProvider, name, strings.Join(KnownClouds(), ", "))
+	}
+	return Cloud(name), nil
+}
+
+// KnownClouds returns the sorted names of the known cloud frameworks
+func KnownClouds() []string {
+	cloudsMu.RLock()
+	defer cloudsMu.RUnlock()
+
+	names := make([]string, 0, len(clouds))
+	for name := range clouds {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Info returns the metadata of the cloud framework, only the name is set
+// when the cloud framework is not known
+func (c Cloud) Info() CloudInfo {
+	if info, ok := LookupCloud(string(c)); ok {
+		return info
+	}
+	return CloudInfo{Name: c, DisplayName: string(c)}
+}
+
+func (c Cloud) String() string {
+	return string(c)
+}
@@ -0,0 +1,41 @@
+package images
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCloudRegistry(t *testing.T) {
+	expected := []string{"alibaba", "amazon", "google", "microsoft", "oracle"}
+	if known := KnownClouds(); strings.Join(known, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Unexpected clouds. Got %v, expected %v", known, expected)
+	}
+
+	info := CloudMicrosoft.Info()
+	if info.RegionStyle != RegionStyleDisplayName || info.ImageIDKind != ImageIDURN {
+		t.Fatalf("Unexpected metadata %+v", info)
+	}
+
+	if _, err := ParseCloud("hpcloud"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if info := Cloud("hpcloud").Info(); info.DisplayName != "hpcloud" || info.RegionStyle != "" {
+		t.Fatalf("Unexpected metadata %+v", info)
+	}
+
+	RegisterCloud(CloudInfo{Name: "hpcloud", DisplayName: "HP Helion", RegionStyle: RegionStyleCode})
+	defer func() {
+		cloudsMu.Lock()
+		delete(clouds, "hpcloud")
+		cloudsMu.Unlock()
+	}()
+
+	cloud, err := ParseCloud("hpcloud")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if cloud.Info().DisplayName != "HP Helion" {
+		t.Fatalf("Unexpected metadata %+v", cloud.Info())
+	}
+}
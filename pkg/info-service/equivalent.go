@@ -0,0 +1,83 @@
+package images
+
+import (
+	"fmt"
+	"time"
+)
+
+// buildKey identifies the builds of a product published on all the cloud
+// frameworks: the images sharing the key and the build date are the same
+// SUSE build
+type buildKey struct {
+	product string
+	variant string
+	version int
+	sp      int
+	license string
+	arch    string
+}
+
+func newBuildKey(info NameInfo) buildKey {
+	return buildKey{
+		product: info.Product,
+		variant: info.Variant,
+		version: info.Version,
+		sp:      info.SP,
+		license: info.License,
+		arch:    info.Arch,
+	}
+}
+
+func (k buildKey) String() string {
+	return fmt.Sprintf("%s %s %d SP%d %s %s", k.product, k.variant, k.version, k.sp, k.license, k.arch)
+}
+
+// Equivalence is the image of a cloud framework equivalent to an image of
+// another cloud framework
+type Equivalence struct {
+	Image Image
+	// Exact is true when the image has the same build date of the reference
+	// image, otherwise Image is the build nearest to the reference one
+	Exact bool
+}
+
+// FindEquivalent returns the image of candidates equivalent to the
+// reference, usually published on another cloud framework. The images are
+// equivalent when their names, see ParseName, share the product, the
+// variant, the version, the service pack, the license and the architecture.
+// The image with the same build date is preferred, the nearest build is
+// returned otherwise, the newer one on ties. Nothing is returned when no
+// candidate is equivalent. The builds are only compared by their build date:
+// nothing is returned when the name of the reference has no build date, and
+// the candidates without one are skipped.
+func FindEquivalent(reference Image, candidates []Image) (Equivalence, bool) {
+	ref := ParseName(reference.Name)
+	if ref.BuildDate.IsZero() {
+		return Equivalence{}, false
+	}
+	key := newBuildKey(ref)
+
+	var best Equivalence
+	var bestDistance time.Duration
+	found := false
+	for _, candidate := range candidates {
+		info := ParseName(candidate.Name)
+		if info.BuildDate.IsZero() || newBuildKey(info) != key {
+			continue
+		}
+
+		distance := info.BuildDate.Sub(ref.BuildDate)
+		if distance < 0 {
+			distance = -distance
+		}
+		newer := found && distance == bestDistance &&
+			info.BuildDate.After(ParseName(best.Image.Name).BuildDate)
+		if !found || distance < bestDistance || newer {
+			best = Equivalence{Image: candidate, Exact: distance == 0}
+			bestDistance = distance
+			found = true
+		}
+	}
+
+	return best, found
+}
@@ -0,0 +1,56 @@
+package images
+
+import "testing"
+
+func TestFindEquivalent(t *testing.T) {
+	reference := Image{Name: "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64", ID: "ami-1"}
+	azure := []Image{
+		{Name: "suse-sles-15-sp1-v20190624", ID: "payg"},
+		{Name: "suse-sles-15-sp1-byos-v20190624-arm64", ID: "arm64"},
+		{Name: "suse-sles-sap-15-sp1-byos-v20190624", ID: "sap"},
+		{Name: "suse-sles-15-sp1-byos-v20190301", ID: "older"},
+		{Name: "suse-sles-15-sp1-byos-v20190624", ID: "exact"},
+		{Name: "suse-sles-15-sp1-byos-v20190915", ID: "newer"},
+	}
+
+	e, found := FindEquivalent(reference, azure)
+	if !found || !e.Exact || e.Image.ID != "exact" {
+		t.Fatalf("Unexpected equivalence %+v %v", e, found)
+	}
+
+	// the nearest build is returned when the same build is not published
+	e, found = FindEquivalent(reference, azure[:4])
+	if !found || e.Exact || e.Image.ID != "older" {
+		t.Fatalf("Unexpected equivalence %+v %v", e, found)
+	}
+	e, found = FindEquivalent(reference, append(azure[:4:4], azure[5]))
+	if !found || e.Exact || e.Image.ID != "newer" {
+		t.Fatalf("Unexpected equivalence %+v %v", e, found)
+	}
+
+	// ties are won by the newer build
+	e, found = FindEquivalent(Image{Name: "suse-sles-15-sp1-byos-v20190615-hvm-ssd-x86_64"}, []Image{
+		{Name: "suse-sles-15-sp1-byos-v20190610", ID: "older"},
+		{Name: "suse-sles-15-sp1-byos-v20190620", ID: "newer"},
+	})
+	if !found || e.Exact || e.Image.ID != "newer" {
+		t.Fatalf("Unexpected equivalence %+v %v", e, found)
+	}
+
+	if _, found := FindEquivalent(Image{Name: "suse-sles-12-sp5-v20200101-hvm-ssd-x86_64"}, azure); found {
+		t.Fatalf("No equivalence was expected")
+	}
+
+	// the builds without a build date are not comparable
+	undated := []Image{{Name: "suse-sles-15-sp1-byos-hvm-ssd-x86_64", ID: "undated"}}
+	if e, found := FindEquivalent(undated[0], append(undated, azure...)); found {
+		t.Fatalf("Unexpected equivalence %+v", e)
+	}
+	if e, found := FindEquivalent(reference, undated); found {
+		t.Fatalf("Unexpected equivalence %+v", e)
+	}
+	e, found = FindEquivalent(reference, append(undated, azure[3]))
+	if !found || e.Exact || e.Image.ID != "older" {
+		t.Fatalf("Unexpected equivalence %+v %v", e, found)
+	}
+}
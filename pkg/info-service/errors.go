@@ -0,0 +1,152 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors returned by the package, they can be tested with errors.Is:
+//
+//	if _, err := images.GetImages(params); errors.Is(err, images.ErrUnknownRegion) {
+//		...
+//	}
+var (
+	// ErrNotFound is returned by the sources when a document does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrUnknownProvider is returned when the cloud framework is not known
+	// by the API
+	ErrUnknownProvider = errors.New("unknown cloud framework")
+	// ErrUnknownRegion is returned when the region is not known by the API
+	ErrUnknownRegion = errors.New("unknown region")
+	// ErrInvalidFilter is returned when a filter expression cannot be
+	// compiled
+	ErrInvalidFilter = errors.New("invalid filter expression")
+	// ErrInvalidNameRegex is returned when the name regular expression of
+	// the search criteria cannot be compiled
+	ErrInvalidNameRegex = errors.New("invalid name regular expression")
+	// ErrDecode is returned when a document of the API cannot be decoded
+	ErrDecode = errors.New("error while decoding remote response")
+)
+
+// maxErrorBody is the maximum number of bytes of the reply kept by an
+// HTTPError
+const maxErrorBody = 512
+
+// HTTPError is returned by HTTPSource when the info service replies with an
+// unexpected HTTP status. Replies with status 404 match ErrNotFound.
+type HTTPError struct {
+	StatusCode int
+	// URL is the address of the document, without credentials
+	URL string
+	// Body holds the beginning of the reply, truncated to 512 bytes
+	Body string
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("unexpected HTTP status %d while accessing %s", e.StatusCode, e.URL)
+	if body := strings.TrimSpace(e.Body); body != "" {
+		msg += ": " + body
+	}
+	return msg
+}
+
+// Is reports whether the error matches ErrNotFound
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// failoverError reports the failure of all the sources of a FailoverSource
+type failoverError struct {
+	sources []Source
+	errs    []error
+}
+
+func (e *failoverError) Error() string {
+	failures := make([]string, 0, len(e.errs))
+	for i, err := range e.errs {
+		failures = append(failures, fmt.Sprintf("%s: %v", e.sources[i], err))
+	}
+	return fmt.Sprintf("all the sources failed:\n  %s", strings.Join(failures, "\n  "))
+}
+
+func (e *failoverError) Unwrap() []error {
+	return e.errs
+}
+
+// classifyNotFound replaces a document not found error with
+// ErrUnknownProvider or ErrUnknownRegion when the cloud framework or the
+// region, which can be empty, are not known by the API
+func classifyNotFound(err error, src Source, version, cloud, region string) error {
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	providers, e := knownBySources(src, func(s Source) ([]string, error) {
+		return GetProvidersFrom(s, version)
+	})
+	if e != nil {
+		return err
+	}
+	if !contains(providers, cloud) {
+		return fmt.Errorf("%w %q, expected one of %s: %w",
+			ErrUnknownProvider, cloud, strings.Join(providers, ", "), err)
+	}
+	if region == "" {
+		return err
+	}
+
+	regions, e := knownBySources(src, func(s Source) ([]string, error) {
+		return GetRegionsFrom(s, version, cloud)
+	})
+	if e != nil {
+		return err
+	}
+	if !contains(regions, region) {
+		return fmt.Errorf("%w %q of %s: %w", ErrUnknownRegion, region, cloud, err)
+	}
+
+	return err
+}
+
+// knownBySources returns the values listed by any of the sources of a
+// FailoverSource, since a mirror can serve a part of the cloud frameworks
+// only, or the values listed by src otherwise. An error is returned when no
+// source lists them.
+func knownBySources(src Source, list func(Source) ([]string, error)) ([]string, error) {
+	failover, ok := src.(*FailoverSource)
+	if !ok {
+		return list(src)
+	}
+
+	var known []string
+	var err error
+	listed := false
+	for _, s := range failover.Sources {
+		values, e := list(s)
+		if e != nil {
+			err = e
+			continue
+		}
+		listed = true
+		for _, v := range values {
+			if !contains(known, v) {
+				known = append(known, v)
+			}
+		}
+	}
+	if !listed {
+		return nil, err
+	}
+	return known, nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/providers.json":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, strings.Repeat("x", 2*maxErrorBody))
+		default:
+			_, _ = fmt.Fprint(w, `{"images": [{"name": `)
+		}
+	}))
+	defer srv.Close()
+
+	_, err := GetProvidersFrom(NewHTTPSource(srv.URL), APIVersion)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Unexpected status. Got %d, expected %d", httpErr.StatusCode, http.StatusNotFound)
+	}
+	if len(httpErr.Body) != maxErrorBody {
+		t.Fatalf("Unexpected body length. Got %d, expected %d", len(httpErr.Body), maxErrorBody)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected %v to match ErrNotFound", err)
+	}
+
+	_, err = GetImages(SearchParams{APIEndpoint: srv.URL, Cloud: "amazon", Region: "eu-central-1", State: "active"})
+	if !errors.Is(err, ErrDecode) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	src := NewDirectorySource(writeSnapshotDir(t))
+
+	for _, test := range []struct {
+		params   SearchParams
+		expected error
+	}{
+		{
+			params:   SearchParams{Cloud: "hpcloud", Region: "eu-central-1", State: "active"},
+			expected: ErrUnknownProvider,
+		},
+		{
+			params:   SearchParams{Cloud: "amazon", Region: "mars-north-1", State: "active"},
+			expected: ErrUnknownRegion,
+		},
+		{
+			params:   SearchParams{Cloud: "amazon", Region: "eu-central-1", State: "active", NameRegex: "suse-("},
+			expected: ErrInvalidNameRegex,
+		},
+		{
+			params:   SearchParams{Cloud: "amazon", Region: "eu-central-1", State: "active", Filter: "sp >="},
+			expected: ErrInvalidFilter,
+		},
+	} {
+		test.params.Source = src
+		_, err := GetImages(test.params)
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("Unexpected error for %+v. Got %v, expected %v", test.params, err, test.expected)
+		}
+	}
+
+	_, err := GetRegionsFrom(src, APIVersion, "hpcloud")
+	if !errors.Is(err, ErrUnknownProvider) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	catalog := NewCatalog("", APIVersion, "hpcloud")
+	catalog.Source = src
+	_, err = catalog.Search(SearchParams{})
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
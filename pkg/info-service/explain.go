@@ -0,0 +1,120 @@
+package images
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// The search criteria reported by the explanations
+const (
+	CriterionState     = "state"
+	CriterionNameRegex = "name_regex"
+	CriterionFilter    = "filter"
+)
+
+// Explanation tells whether an image has been selected by a search and,
+// when it has not, which criterion excluded it
+type Explanation struct {
+	Image    Image
+	Selected bool
+	// Criterion is the first criterion rejecting the image, like
+	// CriterionNameRegex, empty when the image has been selected
+	Criterion string
+	// Reason describes why the image has been rejected by Criterion
+	Reason string
+}
+
+func (e Explanation) String() string {
+	if e.Selected {
+		return fmt.Sprintf("%s (%s): selected", e.Image.Name, e.Image.ID)
+	}
+	return fmt.Sprintf("%s (%s): excluded by %s, %s", e.Image.Name, e.Image.ID, e.Criterion, e.Reason)
+}
+
+// imageMatcher evaluates the criteria of a search against the images
+type imageMatcher struct {
+	state  string
+	regex  *regexp.Regexp
+	filter *Filter
+}
+
+func newImageMatcher(params SearchParams) (*imageMatcher, error) {
+	m := &imageMatcher{state: params.State}
+
+	if params.NameRegex != "" {
+		r, err := regexp.Compile(params.NameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNameRegex, err)
+		}
+		m.regex = r
+	}
+
+	if params.Filter != "" {
+		filter, err := CompileFilter(params.Filter)
+		if err != nil {
+			return nil, err
+		}
+		m.filter = filter
+	}
+
+	return m, nil
+}
+
+// reject returns the first criterion rejecting the image together with the
+// reason, an empty criterion when the image matches all of them
+func (m *imageMatcher) reject(image Image) (string, string) {
+	if m.state != "" && image.State != m.state {
+		return CriterionState, fmt.Sprintf("state %q is not %q", image.State, m.state)
+	}
+	if m.regex != nil && !m.regex.MatchString(image.Name) {
+		return CriterionNameRegex, fmt.Sprintf("name does not match %q", m.regex)
+	}
+	if m.filter != nil && !m.filter.Match(image) {
+		return CriterionFilter, fmt.Sprintf("filter %q is false", m.filter)
+	}
+	return "", ""
+}
+
+// explainImages returns the explanation of every candidate. The selected
+// images come first, sorted like the results of the search, followed by the
+// excluded ones in their original order.
+func explainImages(candidates []Image, params SearchParams) ([]Explanation, error) {
+	m, err := newImageMatcher(params)
+	if err != nil {
+		return []Explanation{}, err
+	}
+
+	selected := make([]Image, 0)
+	excluded := make([]Explanation, 0)
+	for _, image := range candidates {
+		criterion, reason := m.reject(image)
+		if criterion == "" {
+			selected = append(selected, image)
+			continue
+		}
+		excluded = append(excluded, Explanation{Image: image, Criterion: criterion, Reason: reason})
+	}
+	sortImages(selected, params.SortAscending)
+
+	res := make([]Explanation, 0, len(candidates))
+	for _, image := range selected {
+		res = append(res, Explanation{Image: image, Selected: true})
+	}
+	return append(res, excluded...), nil
+}
+
+// ExplainImages runs the search like GetImages, but returns the explanation
+// of every image of the region instead of the matching images only. The
+// images of all the states are fetched, hence the ones excluded by the state
+// are reported as well.
+func ExplainImages(params SearchParams) ([]Explanation, error) {
+	if err := ValidateState(params.State); err != nil {
+		return []Explanation{}, err
+	}
+	candidates, err := GetAllImages(params)
+	if err != nil {
+		return []Explanation{}, err
+	}
+
+	return explainImages(candidates, params)
+}
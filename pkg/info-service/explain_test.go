@@ -0,0 +1,92 @@
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExplainImages(t *testing.T) {
+	candidates := []Image{
+		{Name: "suse-sles-15-sp1-v20190301-hvm-ssd-x86_64", State: "deprecated", ID: "ami-1", PublishedOn: "20190301"},
+		{Name: "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64", State: "active", ID: "ami-2", PublishedOn: "20190624"},
+		{Name: "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64", State: "active", ID: "ami-3", PublishedOn: "20190624"},
+		{Name: "suse-sles-15-sp1-v20190624-hvm-ssd-arm64", State: "active", ID: "ami-4", PublishedOn: "20190624"},
+		{Name: "suse-sles-15-sp1-v20190701-hvm-ssd-x86_64", State: "active", ID: "ami-5", PublishedOn: "20190701"},
+	}
+	params := SearchParams{
+		State:     "active",
+		NameRegex: "suse-sles-15-sp1-v.*",
+		Filter:    `arch == "x86_64"`,
+	}
+
+	explanations, err := explainImages(candidates, params)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []struct {
+		id        string
+		selected  bool
+		criterion string
+	}{
+		{"ami-5", true, ""},
+		{"ami-2", true, ""},
+		{"ami-1", false, CriterionState},
+		{"ami-3", false, CriterionNameRegex},
+		{"ami-4", false, CriterionFilter},
+	}
+	if len(explanations) != len(expected) {
+		t.Fatalf("Unexpected number of explanations. Got %d, expected %d", len(explanations), len(expected))
+	}
+	for i, e := range expected {
+		got := explanations[i]
+		if got.Image.ID != e.id || got.Selected != e.selected || got.Criterion != e.criterion {
+			t.Fatalf("Unexpected explanation %d. Got %s, expected %s excluded by %q", i, got, e.id, e.criterion)
+		}
+		if !got.Selected && got.Reason == "" {
+			t.Fatalf("Expected a reason for %s", got)
+		}
+	}
+
+	// the selected images are the ones returned by the search
+	found, err := filterImages(candidates, params)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 2 || found[0].ID != "ami-5" || found[1].ID != "ami-2" {
+		t.Fatalf("Unexpected images %+v", found)
+	}
+}
+
+func TestExplainImagesFetchesAllStates(t *testing.T) {
+	var requested string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		_, _ = w.Write([]byte(`{"images": [
+  {"name": "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64", "state": "active", "id": "ami-2", "region": "eu-central-1"},
+  {"name": "suse-sles-15-sp1-v20190301-hvm-ssd-x86_64", "state": "inactive", "id": "ami-1", "region": "eu-central-1"}
+]}`))
+	}))
+	defer srv.Close()
+
+	explanations, err := ExplainImages(SearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		State:       "active",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if requested != "/v1/amazon/eu-central-1/images.json" {
+		t.Fatalf("Unexpected path. Got %s, expected %s", requested, "/v1/amazon/eu-central-1/images.json")
+	}
+	if len(explanations) != 2 || !explanations[0].Selected || explanations[1].Criterion != CriterionState {
+		t.Fatalf("Unexpected explanations %v", explanations)
+	}
+
+	if _, err := ExplainImages(SearchParams{APIEndpoint: srv.URL, Cloud: "amazon", State: "gone"}); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
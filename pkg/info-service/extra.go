@@ -0,0 +1,172 @@
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// imageFields maps the lower case names of the fields of the image
+// documents mapped by Image to the index of their field
+var imageFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Image{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// image has the fields of Image without its methods, it is used to decode
+// and encode the known fields
+type image Image
+
+// xmlImage is the XML representation of an Image, the attributes not mapped
+// by Image are collected into Attrs
+type xmlImage struct {
+	image
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// UnmarshalJSON decodes an image document, the fields unknown to Image are
+// stored inside of Extra and the document itself inside of Raw. The
+// document is parsed once, each field is then decoded on its own.
+func (i *Image) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var known image
+	value := reflect.ValueOf(&known).Elem()
+	for name, raw := range fields {
+		if index, ok := imageFields[strings.ToLower(name)]; ok {
+			if err := json.Unmarshal(raw, value.Field(index).Addr().Interface()); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if known.Extra == nil {
+			known.Extra = make(map[string]interface{})
+		}
+		known.Extra[name] = v
+	}
+	// the decoder may reuse data
+	known.Raw = append(json.RawMessage(nil), data...)
+
+	*i = Image(known)
+	return nil
+}
+
+// MarshalJSON encodes the image together with its Extra fields, the known
+// fields take precedence. The document the image has been decoded from is
+// returned as it is when the image has not been modified since.
+func (i Image) MarshalJSON() ([]byte, error) {
+	if len(i.Raw) > 0 {
+		var decoded Image
+		if err := decoded.UnmarshalJSON(i.Raw); err == nil && reflect.DeepEqual(decoded, i) {
+			return i.Raw, nil
+		}
+	}
+
+	data, err := json.Marshal(image(i))
+	if err != nil || len(i.Extra) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]interface{}, len(i.Extra))
+	for name, v := range i.Extra {
+		fields[name] = v
+	}
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, err
+	}
+	for name, raw := range known {
+		fields[name] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalXML decodes an image element, the attributes unknown to Image
+// are stored inside of Extra
+func (i *Image) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v xmlImage
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	v.Extra = nil
+	for _, attr := range v.Attrs {
+		if v.Extra == nil {
+			v.Extra = make(map[string]interface{})
+		}
+		v.Extra[attr.Name.Local] = attr.Value
+	}
+
+	*i = Image(v.image)
+	return nil
+}
+
+// MarshalXML encodes the image together with its Extra fields
+func (i Image) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := xmlImage{image: image(i)}
+	for name, value := range i.ExtraStrings() {
+		if _, ok := imageFields[strings.ToLower(name)]; ok {
+			continue
+		}
+		v.Attrs = append(v.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+	sort.Slice(v.Attrs, func(a, b int) bool {
+		return v.Attrs[a].Name.Local < v.Attrs[b].Name.Local
+	})
+	return e.EncodeElement(v, start)
+}
+
+// ExtraStrings returns the Extra fields of the image as strings, the values
+// which are not strings, like numbers or objects, are encoded as JSON
+func (i Image) ExtraStrings() map[string]string {
+	res := make(map[string]string, len(i.Extra))
+	for name, v := range i.Extra {
+		if s, ok := v.(string); ok {
+			res[name] = s
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			data = []byte(fmt.Sprint(v))
+		}
+		res[name] = string(data)
+	}
+	return res
+}
+
+// RawJSON returns the JSON document the image has been decoded from, with
+// the fields and their order left as they are, compacted. The images that
+// have not been decoded from JSON are encoded, including their Extra fields.
+func (i Image) RawJSON() (string, error) {
+	if len(i.Raw) > 0 {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, i.Raw); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
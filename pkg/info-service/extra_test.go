@@ -0,0 +1,138 @@
+package images
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestImageExtraJSON(t *testing.T) {
+	doc := `{
+  "name": "suse-sles-15-sp1-v20190624",
+  "state": "active",
+  "id": "SUSE:sles-15-sp1:gen1:2019.06.24",
+  "region": "East US 2",
+  "urn": "SUSE:sles-15-sp1:gen1:2019.06.24",
+  "environment": "PublicAzure",
+  "generation": 1,
+  "tags": ["sap"]
+}`
+
+	var image Image
+	if err := json.Unmarshal([]byte(doc), &image); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if image.ID != "SUSE:sles-15-sp1:gen1:2019.06.24" || image.Region != "East US 2" {
+		t.Fatalf("Unexpected image %+v", image)
+	}
+	if len(image.Extra) != 4 {
+		t.Fatalf("Unexpected number of extra fields. Got %d, expected %d", len(image.Extra), 4)
+	}
+
+	extra := image.ExtraStrings()
+	for name, expected := range map[string]string{
+		"urn":         "SUSE:sles-15-sp1:gen1:2019.06.24",
+		"environment": "PublicAzure",
+		"generation":  "1",
+		"tags":        `["sap"]`,
+	} {
+		if extra[name] != expected {
+			t.Fatalf("Unexpected %s. Got %s, expected %s", name, extra[name], expected)
+		}
+	}
+
+	raw, err := image.RawJSON()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := `{"name":"suse-sles-15-sp1-v20190624","state":"active","id":"SUSE:sles-15-sp1:gen1:2019.06.24",` +
+		`"region":"East US 2","urn":"SUSE:sles-15-sp1:gen1:2019.06.24","environment":"PublicAzure",` +
+		`"generation":1,"tags":["sap"]}`
+	if raw != expected {
+		t.Fatalf("Unexpected raw document. Got %s, expected %s", raw, expected)
+	}
+	var decoded Image
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if decoded.Name != image.Name || decoded.ExtraStrings()["tags"] != `["sap"]` {
+		t.Fatalf("Unexpected image %+v", decoded)
+	}
+
+	var plain Image
+	if err := json.Unmarshal([]byte(`{"name": "sles", "id": "ami-1"}`), &plain); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if plain.Extra != nil {
+		t.Fatalf("Unexpected extra fields %v", plain.Extra)
+	}
+}
+
+func TestImageRawJSON(t *testing.T) {
+	doc := `{"name": "sles", "state": "active", "deprecatedon": "", "id": "ami-1", "urn": "x"}`
+
+	var image Image
+	if err := json.Unmarshal([]byte(doc), &image); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	raw, err := image.RawJSON()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	compact := `{"name":"sles","state":"active","deprecatedon":"","id":"ami-1","urn":"x"}`
+	if raw != compact {
+		t.Fatalf("Unexpected raw document. Got %s, expected %s", raw, compact)
+	}
+
+	// the unmodified images are encoded as they have been received
+	data, err := json.Marshal(image)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if string(data) != compact {
+		t.Fatalf("Unexpected document. Got %s, expected %s", data, compact)
+	}
+
+	image.State = "deprecated"
+	data, err = json.Marshal(image)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var modified Image
+	if err := json.Unmarshal(data, &modified); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if modified.State != "deprecated" || modified.ExtraStrings()["urn"] != "x" {
+		t.Fatalf("Unexpected image %+v", modified)
+	}
+
+	built := Image{Name: "sles", ID: "ami-1", Extra: map[string]interface{}{"urn": "x"}}
+	if raw, err := built.RawJSON(); err != nil || !strings.Contains(raw, `"urn":"x"`) {
+		t.Fatalf("Unexpected raw document %s (%v)", raw, err)
+	}
+}
+
+func TestImageExtraXML(t *testing.T) {
+	doc := `<images><image name="sles-15-sp1-v20190624" state="active" id="sles-15-sp1-v20190624" region="us-east1" project="suse-cloud"/></images>`
+
+	var reply imagesReply
+	if err := xml.Unmarshal([]byte(doc), &reply); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(reply.Images) != 1 {
+		t.Fatalf("Unexpected number of images. Got %d, expected %d", len(reply.Images), 1)
+	}
+	image := reply.Images[0]
+	if image.State != "active" || image.Extra["project"] != "suse-cloud" {
+		t.Fatalf("Unexpected image %+v", image)
+	}
+
+	data, err := xml.Marshal(image)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(string(data), `project="suse-cloud"`) {
+		t.Fatalf("Unexpected document %s", data)
+	}
+}
@@ -0,0 +1,209 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFailoverCooldown is the amount of time a failing source is skipped
+// by a FailoverSource
+const DefaultFailoverCooldown = 5 * time.Minute
+
+// FailoverSource is a Source trying a list of sources in order, for example
+// an internal mirror of the info service first and the public instance
+// second.
+//
+// A source failing to provide a document is marked as unhealthy and skipped
+// for the Cooldown period, unless all the sources are unhealthy. Documents
+// missing from a source, reported with ErrNotFound, are looked up in the
+// next sources without marking the source as unhealthy: a mirror can serve
+// some cloud frameworks only, or not be synchronized yet. The document is
+// reported as missing once all the sources miss it.
+type FailoverSource struct {
+	Sources []Source
+	// Cooldown is the amount of time an unhealthy source is skipped,
+	// defaults to DefaultFailoverCooldown
+	Cooldown time.Duration
+
+	// health is shared with the tracked copies of the source, once
+	// allocates it lazily
+	once   sync.Once
+	health *failoverHealth
+	// served is the source that provided the last document opened through
+	// this copy, guarded by health.mu
+	served Source
+}
+
+type failoverHealth struct {
+	mu sync.Mutex
+	// unhealthy maps the index of the failing sources to the end of their
+	// cooldown
+	unhealthy map[int]time.Time
+	now       func() time.Time
+}
+
+// NewFailoverSource returns a Source trying the given sources in order
+func NewFailoverSource(sources ...Source) *FailoverSource {
+	return &FailoverSource{
+		Sources:  sources,
+		Cooldown: DefaultFailoverCooldown,
+		health:   newFailoverHealth(),
+	}
+}
+
+func newFailoverHealth() *failoverHealth {
+	return &failoverHealth{unhealthy: make(map[int]time.Time), now: time.Now}
+}
+
+// state returns the health of the sources, allocating it when the source
+// has not been created by NewFailoverSource
+func (f *FailoverSource) state() *failoverHealth {
+	f.once.Do(func() {
+		if f.health == nil {
+			f.health = newFailoverHealth()
+		}
+	})
+	return f.health
+}
+
+// NewEndpointsSource returns a Source querying the instances of the info
+// service found at the given endpoints in order. All the requests carry the
+// given credentials, when set.
+func NewEndpointsSource(endpoints []string, client *http.Client, auth *Auth) *FailoverSource {
+	sources := make([]Source, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		sources = append(sources, &HTTPSource{Endpoint: endpoint, Client: client, Auth: auth})
+	}
+	return NewFailoverSource(sources...)
+}
+
+// Track returns a copy of the source sharing the health of its sources,
+// whose Served method reports only the documents opened through the copy
+func (f *FailoverSource) Track() *FailoverSource {
+	return &FailoverSource{Sources: f.Sources, Cooldown: f.Cooldown, health: f.state()}
+}
+
+// Served returns the source that provided the last document opened, nil
+// when no document has been opened yet
+func (f *FailoverSource) Served() Source {
+	health := f.state()
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	return f.served
+}
+
+// Unhealthy returns the sources currently skipped because of a failure
+func (f *FailoverSource) Unhealthy() []Source {
+	health := f.state()
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	res := make([]Source, 0)
+	now := health.now()
+	for i, src := range f.Sources {
+		if until, ok := health.unhealthy[i]; ok && now.Before(until) {
+			res = append(res, src)
+		}
+	}
+	return res
+}
+
+// Open returns the document provided by the first healthy source. The
+// unhealthy sources are tried last, when all the healthy ones failed. The
+// error of the first source is returned when the document is missing from
+// all of them.
+func (f *FailoverSource) Open(p, query string) (io.ReadCloser, error) {
+	if len(f.Sources) == 0 {
+		return nil, fmt.Errorf("no source of the API documents configured")
+	}
+
+	failures := &failoverError{}
+	var notFound error
+	for _, i := range f.order() {
+		src := f.Sources[i]
+		body, err := src.Open(p, query)
+		if err == nil {
+			f.markHealthy(i, src)
+			return body, nil
+		}
+
+		if len(f.Sources) == 1 {
+			return nil, err
+		}
+		if errors.Is(err, ErrNotFound) {
+			log.Printf("[DEBUG] %s is missing from %s, trying the next source", p, src)
+			f.markHealthy(i, src)
+			if notFound == nil {
+				notFound = err
+			}
+			continue
+		}
+
+		log.Printf("[WARN] %s failed, trying the next source: %v", src, err)
+		f.markUnhealthy(i)
+		failures.sources = append(failures.sources, src)
+		failures.errs = append(failures.errs, err)
+	}
+
+	if len(failures.errs) == 0 {
+		return nil, notFound
+	}
+	return nil, failures
+}
+
+// order returns the indexes of the healthy sources followed by the
+// unhealthy ones
+func (f *FailoverSource) order() []int {
+	health := f.state()
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	now := health.now()
+	healthy := make([]int, 0, len(f.Sources))
+	unhealthy := make([]int, 0)
+	for i := range f.Sources {
+		if until, ok := health.unhealthy[i]; ok && now.Before(until) {
+			unhealthy = append(unhealthy, i)
+			continue
+		}
+		healthy = append(healthy, i)
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (f *FailoverSource) markHealthy(i int, src Source) {
+	health := f.state()
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	delete(health.unhealthy, i)
+	f.served = src
+}
+
+func (f *FailoverSource) markUnhealthy(i int) {
+	health := f.state()
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	cooldown := f.Cooldown
+	if cooldown == 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	health.unhealthy[i] = health.now().Add(cooldown)
+}
+
+func (f *FailoverSource) String() string {
+	names := make([]string, 0, len(f.Sources))
+	for _, src := range f.Sources {
+		names = append(names, src.String())
+	}
+	return fmt.Sprintf("failover(%s)", strings.Join(names, ", "))
+}
@@ -0,0 +1,187 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEndpoint is an info service replying with the given status, the
+// number of requests received is counted
+type testEndpoint struct {
+	*httptest.Server
+	status   int
+	requests int
+}
+
+func newTestEndpoint(t *testing.T, status int) *testEndpoint {
+	e := &testEndpoint{status: status}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.requests++
+		w.WriteHeader(e.status)
+		if e.status == http.StatusOK {
+			_, _ = fmt.Fprint(w, `{"providers": [{"name": "amazon"}]}`)
+		}
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func TestFailoverSource(t *testing.T) {
+	mirror := newTestEndpoint(t, http.StatusServiceUnavailable)
+	public := newTestEndpoint(t, http.StatusOK)
+
+	now := time.Date(2019, 10, 10, 12, 0, 0, 0, time.UTC)
+	src := NewEndpointsSource([]string{mirror.URL, public.URL}, nil, nil)
+	src.Cooldown = time.Minute
+	src.health.now = func() time.Time { return now }
+
+	tracked := src.Track()
+	if _, err := GetProvidersFrom(tracked, APIVersion); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if tracked.Served().String() != public.URL {
+		t.Fatalf("Unexpected source. Got %v, expected %s", tracked.Served(), public.URL)
+	}
+	if unhealthy := src.Unhealthy(); len(unhealthy) != 1 || unhealthy[0].String() != mirror.URL {
+		t.Fatalf("Unexpected unhealthy sources %v", unhealthy)
+	}
+
+	// the mirror is skipped during the cooldown, even once it recovered
+	mirror.status = http.StatusOK
+	if _, err := GetProvidersFrom(src, APIVersion); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if mirror.requests != 1 {
+		t.Fatalf("Unexpected number of requests. Got %d, expected %d", mirror.requests, 1)
+	}
+	if src.Served().String() != public.URL {
+		t.Fatalf("Unexpected source. Got %v, expected %s", src.Served(), public.URL)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := GetProvidersFrom(src, APIVersion); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if src.Served().String() != mirror.URL {
+		t.Fatalf("Unexpected source. Got %v, expected %s", src.Served(), mirror.URL)
+	}
+	if len(src.Unhealthy()) != 0 {
+		t.Fatalf("Unexpected unhealthy sources %v", src.Unhealthy())
+	}
+
+	// the tracked copy only reports its own documents
+	if tracked.Served().String() != public.URL {
+		t.Fatalf("Unexpected source. Got %v, expected %s", tracked.Served(), public.URL)
+	}
+}
+
+func TestFailoverSourceErrors(t *testing.T) {
+	missing := newTestEndpoint(t, http.StatusNotFound)
+	broken := newTestEndpoint(t, http.StatusInternalServerError)
+	public := newTestEndpoint(t, http.StatusOK)
+
+	// missing documents are looked up in the next sources, the source
+	// missing them is not unhealthy
+	src := NewEndpointsSource([]string{missing.URL, public.URL}, nil, nil)
+	if _, err := GetProvidersFrom(src, APIVersion); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if public.requests != 1 || len(src.Unhealthy()) != 0 {
+		t.Fatalf("Unexpected failover")
+	}
+
+	// the documents missing from all the sources are reported as missing
+	src = NewEndpointsSource([]string{missing.URL, missing.URL}, nil, nil)
+	if _, err := GetProvidersFrom(src, APIVersion); !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// all the sources are unhealthy, they are tried anyway
+	src = NewEndpointsSource([]string{broken.URL, broken.URL}, nil, nil)
+	for i := 0; i < 2; i++ {
+		_, err := GetProvidersFrom(src, APIVersion)
+		if err == nil || !strings.Contains(err.Error(), "all the sources failed") {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if broken.requests != 4 {
+		t.Fatalf("Unexpected number of requests. Got %d, expected %d", broken.requests, 4)
+	}
+
+	if _, err := (&FailoverSource{}).Open("v1/providers.json", ""); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestFailoverSourcePartialMirror(t *testing.T) {
+	// the mirror serves the amazon documents only
+	mirror := httptest.NewServer(http.FileServer(http.Dir(writeSnapshotDir(t))))
+	t.Cleanup(mirror.Close)
+
+	full := t.TempDir()
+	for name, data := range map[string]string{
+		"v1/providers.json":                         `{"providers": [{"name": "amazon"}, {"name": "microsoft"}]}`,
+		"v1/microsoft/regions.json":                 `{"regions": [{"name": "East US 2"}]}`,
+		"v1/microsoft/East US 2/images/active.json": `{"images": [{"name": "suse-sles-15-sp1-v20190624", "id": "urn", "state": "active"}]}`,
+	} {
+		p := filepath.Join(full, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	src := NewFailoverSource(&HTTPSource{Endpoint: mirror.URL}, NewDirectorySource(full))
+	found, err := GetImages(SearchParams{Cloud: "microsoft", Region: "East US 2", State: "active", Source: src})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "urn" {
+		t.Fatalf("Unexpected images %+v", found)
+	}
+	if len(src.Unhealthy()) != 0 {
+		t.Fatalf("Unexpected unhealthy sources %v", src.Unhealthy())
+	}
+
+	// the cloud frameworks and the regions are unknown when no source
+	// lists them
+	_, err = GetImages(SearchParams{Cloud: "microsoft", Region: "West Europe", State: "active", Source: src})
+	if !errors.Is(err, ErrUnknownRegion) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	_, err = GetImages(SearchParams{Cloud: "oracle", Region: "us-ashburn-1", State: "active", Source: src})
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestFailoverSourceTimeout(t *testing.T) {
+	if defaultHTTPClient.Timeout != DefaultHTTPTimeout {
+		t.Fatalf("Unexpected default timeout. Got %s, expected %s", defaultHTTPClient.Timeout, DefaultHTTPTimeout)
+	}
+
+	// the endpoint hangs until the request is cancelled
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(hanging.Close)
+	public := newTestEndpoint(t, http.StatusOK)
+
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	src := NewEndpointsSource([]string{hanging.URL, public.URL}, client, nil)
+	if _, err := GetProvidersFrom(src, APIVersion); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if unhealthy := src.Unhealthy(); len(unhealthy) != 1 || unhealthy[0].String() != hanging.URL {
+		t.Fatalf("Unexpected unhealthy sources %v", unhealthy)
+	}
+}
@@ -0,0 +1,160 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// Catalog holds the data served by a fake info service, indexed by the
+// name of the cloud framework
+type Catalog struct {
+	Images  map[string][]images.Image
+	Servers map[string][]images.Server
+	// Regions is optional, when a cloud framework has no entry the regions
+	// are computed from its images and servers
+	Regions map[string][]string
+	// DataVersion is optional, the version of a cloud framework defaults
+	// to "1"
+	DataVersion map[string]string
+}
+
+// LoadDir loads a catalog from a fixture directory. Each cloud framework is
+// a sub-directory holding any of the following files, using the same format
+// of the API replies:
+//
+//	<dir>/<cloud>/images.json   {"images": [...]}
+//	<dir>/<cloud>/servers.json  {"servers": [...]}
+//	<dir>/<cloud>/regions.json  {"regions": [{"name": "..."}, ...]}
+//	<dir>/<cloud>/version       data version, as plain text
+func LoadDir(dir string) (*Catalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		Images:      make(map[string][]images.Image),
+		Servers:     make(map[string][]images.Server),
+		Regions:     make(map[string][]string),
+		DataVersion: make(map[string]string),
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		cloud := entry.Name()
+		cloudDir := filepath.Join(dir, cloud)
+
+		var imagesFile struct {
+			Images []images.Image `json:"images"`
+		}
+		if err := readJSON(filepath.Join(cloudDir, "images.json"), &imagesFile); err != nil {
+			return nil, err
+		}
+		catalog.Images[cloud] = imagesFile.Images
+
+		var serversFile struct {
+			Servers []images.Server `json:"servers"`
+		}
+		if err := readJSON(filepath.Join(cloudDir, "servers.json"), &serversFile); err != nil {
+			return nil, err
+		}
+		catalog.Servers[cloud] = serversFile.Servers
+
+		var regionsFile struct {
+			Regions []struct {
+				Name string `json:"name"`
+			} `json:"regions"`
+		}
+		if err := readJSON(filepath.Join(cloudDir, "regions.json"), &regionsFile); err != nil {
+			return nil, err
+		}
+		for _, r := range regionsFile.Regions {
+			catalog.Regions[cloud] = append(catalog.Regions[cloud], r.Name)
+		}
+
+		version, err := os.ReadFile(filepath.Join(cloudDir, "version"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if len(version) > 0 {
+			catalog.DataVersion[cloud] = string(bytes.TrimSpace(version))
+		}
+	}
+
+	return catalog, nil
+}
+
+// readJSON decodes the given file into v, missing files are ignored
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error while decoding %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// providers returns the sorted names of the cloud frameworks of the catalog
+func (c *Catalog) providers() []string {
+	set := make(map[string]bool)
+	for cloud := range c.Images {
+		set[cloud] = true
+	}
+	for cloud := range c.Servers {
+		set[cloud] = true
+	}
+	for cloud := range c.Regions {
+		set[cloud] = true
+	}
+
+	return sortedKeys(set)
+}
+
+// regions returns the sorted regions of a cloud framework
+func (c *Catalog) regions(cloud string) []string {
+	if regions, ok := c.Regions[cloud]; ok && len(regions) > 0 {
+		return regions
+	}
+
+	set := make(map[string]bool)
+	for _, image := range c.Images[cloud] {
+		set[image.Region] = true
+	}
+	for _, server := range c.Servers[cloud] {
+		set[server.Region] = true
+	}
+	delete(set, "")
+
+	return sortedKeys(set)
+}
+
+func (c *Catalog) dataVersion(cloud string) string {
+	if v, ok := c.DataVersion[cloud]; ok && v != "" {
+		return v
+	}
+	return "1"
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,344 @@
+// Package fake implements a fake SUSE public cloud info service, useful to
+// test code built on top of the info-service package or of the terraform
+// provider without accessing the public instance of the service.
+//
+//	srv := fake.NewServer(catalog)
+//	defer srv.Close()
+//
+//	found, err := images.GetImages(images.SearchParams{
+//		APIEndpoint: srv.URL,
+//		...
+//	})
+//
+// The documents are served both in the JSON and in the XML format, see
+// images.FormatXML.
+//
+// Faults like latency, HTTP errors and malformed payloads can be injected
+// using hooks.
+package fake
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// Fault describes how a request has to be altered by a Hook
+type Fault struct {
+	// Latency is waited before replying
+	Latency time.Duration
+	// StatusCode, when set, is returned instead of the regular reply
+	StatusCode int
+	// Body, when set, is returned instead of the regular payload. Useful
+	// to test malformed payloads.
+	Body []byte
+}
+
+// Hook is invoked for each request received by the fake service. The
+// request is served normally when the hook returns nil.
+type Hook func(r *http.Request) *Fault
+
+// WithLatency returns a hook delaying all the replies
+func WithLatency(d time.Duration) Hook {
+	return func(r *http.Request) *Fault {
+		return &Fault{Latency: d}
+	}
+}
+
+// WithStatus returns a hook replying with the given HTTP status code to all
+// the requests whose path starts with prefix
+func WithStatus(prefix string, code int) Hook {
+	return func(r *http.Request) *Fault {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return &Fault{StatusCode: code}
+		}
+		return nil
+	}
+}
+
+// WithMalformedPayload returns a hook replying with a truncated JSON
+// document to all the requests whose path starts with prefix
+func WithMalformedPayload(prefix string) Hook {
+	return func(r *http.Request) *Fault {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return &Fault{Body: []byte(`{"images": [{"name": `)}
+		}
+		return nil
+	}
+}
+
+// WithRequiredHeader returns a hook replying with 401 to all the requests
+// not carrying the header with the given value, like a service sitting
+// behind authentication
+func WithRequiredHeader(name, value string) Hook {
+	return func(r *http.Request) *Fault {
+		if r.Header.Get(name) != value {
+			return &Fault{StatusCode: http.StatusUnauthorized}
+		}
+		return nil
+	}
+}
+
+// Handler is a http.Handler implementing all the endpoints of the info
+// service
+type Handler struct {
+	mu       sync.Mutex
+	catalog  *Catalog
+	hooks    []Hook
+	requests []string
+}
+
+// NewHandler returns a handler serving the given catalog
+func NewHandler(catalog *Catalog, hooks ...Hook) *Handler {
+	return &Handler{catalog: catalog, hooks: hooks}
+}
+
+// SetCatalog replaces the data served by the handler
+func (h *Handler) SetCatalog(catalog *Catalog) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.catalog = catalog
+}
+
+// AddHook adds a hook invoked on every request, after the ones already
+// registered
+func (h *Handler) AddHook(hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.hooks = append(h.hooks, hook)
+}
+
+// ResetHooks removes all the hooks
+func (h *Handler) ResetHooks() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.hooks = nil
+}
+
+// Requests returns the URIs of the requests received so far
+func (h *Handler) Requests() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string{}, h.requests...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests = append(h.requests, r.RequestURI)
+	catalog := h.catalog
+	hooks := append([]Hook{}, h.hooks...)
+	h.mu.Unlock()
+
+	for _, hook := range hooks {
+		fault := hook(r)
+		if fault == nil {
+			continue
+		}
+		time.Sleep(fault.Latency)
+		if fault.StatusCode != 0 {
+			writeError(w, fault.StatusCode, http.StatusText(fault.StatusCode))
+			return
+		}
+		if fault.Body != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write(fault.Body); err != nil {
+				log.Printf("failed to write reply: %v", err)
+			}
+			return
+		}
+	}
+
+	// the XML documents are routed like the JSON ones
+	p, format := r.URL.Path, images.FormatJSON
+	if strings.HasSuffix(p, ".xml") {
+		p, format = strings.TrimSuffix(p, ".xml"), images.FormatXML
+		if !strings.HasSuffix(p, "/dataversion") {
+			p += ".json"
+		}
+	}
+
+	reply, err := route(catalog, p)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if format == images.FormatXML {
+		w.Header().Set("Content-Type", "application/xml")
+		err = xml.NewEncoder(w).Encode(reply)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(reply)
+	}
+	if err != nil {
+		log.Printf("failed to write reply: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": msg}); err != nil {
+		log.Printf("failed to write reply: %v", err)
+	}
+}
+
+type named struct {
+	Name string `json:"name" xml:"name,attr"`
+}
+
+// The replies of the service, encoded as JSON or XML
+type (
+	providersReply struct {
+		XMLName   xml.Name `json:"-" xml:"providers"`
+		Providers []named  `json:"providers" xml:"provider"`
+	}
+	regionsReply struct {
+		XMLName xml.Name `json:"-" xml:"regions"`
+		Regions []named  `json:"regions" xml:"region"`
+	}
+	dataVersionReply struct {
+		XMLName xml.Name `json:"-" xml:"dataversion"`
+		Version string   `json:"version" xml:"version,attr"`
+	}
+	imagesReply struct {
+		XMLName xml.Name       `json:"-" xml:"images"`
+		Images  []images.Image `json:"images" xml:"image"`
+	}
+	serversReply struct {
+		XMLName xml.Name        `json:"-" xml:"servers"`
+		Servers []images.Server `json:"servers" xml:"server"`
+	}
+)
+
+func names(values []string) []named {
+	res := make([]named, 0, len(values))
+	for _, v := range values {
+		res = append(res, named{Name: v})
+	}
+	return res
+}
+
+// route computes the reply to the request of the given path, the following
+// paths are served, the ".json" suffix being replaced by ".xml" for the XML
+// documents:
+//
+//	/v1/providers.json
+//	/v1/<cloud>/regions.json
+//	/v1/<cloud>/dataversion?category=<images|servers>
+//	/v1/<cloud>[/<region>]/images.json
+//	/v1/<cloud>[/<region>]/images/<state>.json
+//	/v1/<cloud>[/<region>]/servers.json
+//	/v1/<cloud>[/<region>]/servers/<type>.json
+func route(catalog *Catalog, p string) (interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if len(parts) < 2 || parts[0] != images.APIVersion {
+		return nil, fmt.Errorf("unknown path %s", p)
+	}
+	parts = parts[1:]
+
+	if len(parts) == 1 && parts[0] == "providers.json" {
+		return providersReply{Providers: names(catalog.providers())}, nil
+	}
+
+	cloud := parts[0]
+	known := false
+	for _, p := range catalog.providers() {
+		known = known || p == cloud
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown provider %s", cloud)
+	}
+	parts = parts[1:]
+
+	switch {
+	case len(parts) == 1 && parts[0] == "regions.json":
+		return regionsReply{Regions: names(catalog.regions(cloud))}, nil
+	case len(parts) == 1 && parts[0] == "dataversion":
+		return dataVersionReply{Version: catalog.dataVersion(cloud)}, nil
+	}
+
+	region := ""
+	if len(parts) > 0 && parts[0] != "images" && parts[0] != "servers" &&
+		parts[0] != "images.json" && parts[0] != "servers.json" {
+		region = parts[0]
+		known := false
+		for _, r := range catalog.regions(cloud) {
+			known = known || r == region
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown region %s", region)
+		}
+		parts = parts[1:]
+	}
+
+	var kind, filter string
+	switch {
+	case len(parts) == 1 && strings.HasSuffix(parts[0], ".json"):
+		kind = strings.TrimSuffix(parts[0], ".json")
+	case len(parts) == 2 && strings.HasSuffix(parts[1], ".json"):
+		kind = parts[0]
+		filter = strings.TrimSuffix(parts[1], ".json")
+	default:
+		return nil, fmt.Errorf("unknown path %s", p)
+	}
+
+	switch kind {
+	case "images":
+		res := make([]images.Image, 0)
+		for _, image := range catalog.Images[cloud] {
+			if (region == "" || image.Region == region) &&
+				(filter == "" || image.State == filter) {
+				res = append(res, image)
+			}
+		}
+		return imagesReply{Images: res}, nil
+	case "servers":
+		res := make([]images.Server, 0)
+		for _, server := range catalog.Servers[cloud] {
+			if (region == "" || server.Region == region) &&
+				(filter == "" || server.Type == filter) {
+				res = append(res, server)
+			}
+		}
+		return serversReply{Servers: res}, nil
+	}
+
+	return nil, fmt.Errorf("unknown path %s", p)
+}
+
+// Server is a fake info service listening on a random local port
+type Server struct {
+	*Handler
+
+	// URL is the endpoint of the fake service, to be used as APIEndpoint
+	URL string
+
+	srv *httptest.Server
+}
+
+// NewServer starts a fake info service serving the given catalog. The
+// server must be closed by the caller.
+func NewServer(catalog *Catalog, hooks ...Hook) *Server {
+	handler := NewHandler(catalog, hooks...)
+	srv := httptest.NewServer(handler)
+
+	return &Server{Handler: handler, URL: srv.URL, srv: srv}
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
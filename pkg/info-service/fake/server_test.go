@@ -0,0 +1,196 @@
+package fake
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+func newTestServer(t *testing.T, hooks ...Hook) *Server {
+	catalog, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return NewServer(catalog, hooks...)
+}
+
+func TestServeImages(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	found, err := images.GetImages(images.SearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		State:       "active",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "ami-0f9515259be7cd031" {
+		t.Fatalf("Unexpected images %+v", found)
+	}
+
+	catalog := images.NewCatalog(srv.URL, "", "amazon")
+	found, err = catalog.Search(images.SearchParams{State: "active"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(found), 2)
+	}
+
+	expected := []string{
+		"/v1/amazon/eu-central-1/images/active.json",
+		"/v1/amazon/dataversion?category=images",
+		"/v1/amazon/images.json",
+	}
+	requests := srv.Requests()
+	if len(requests) != len(expected) {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Fatalf("Unexpected request. Got %s, expected %s", requests[i], expected[i])
+		}
+	}
+}
+
+func TestServeServersProvidersRegions(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	servers, err := images.GetServers(images.ServerSearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		Type:        "regionserver",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("Unexpected number of servers found. Got %d, expected %d", len(servers), 2)
+	}
+
+	providers, err := images.GetProviders(srv.URL, "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(providers) != 2 || providers[0] != "amazon" || providers[1] != "microsoft" {
+		t.Fatalf("Unexpected providers %v", providers)
+	}
+
+	regions, err := images.GetRegions(srv.URL, "", "amazon")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(regions) != 2 || regions[0] != "eu-central-1" || regions[1] != "us-east-1" {
+		t.Fatalf("Unexpected regions %v", regions)
+	}
+
+	found, err := images.GetImages(images.SearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "microsoft",
+		Region:      "East US 2",
+		State:       "active",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(found), 1)
+	}
+}
+
+func TestServeXML(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	src := &images.HTTPSource{Endpoint: srv.URL, Format: images.FormatXML}
+	found, err := images.GetImages(images.SearchParams{
+		Source: src,
+		Cloud:  "amazon",
+		Region: "eu-central-1",
+		State:  "active",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "ami-0f9515259be7cd031" {
+		t.Fatalf("Unexpected images %+v", found)
+	}
+
+	catalog := images.NewCatalog(srv.URL, "", "amazon")
+	catalog.Source = src
+	found, err = catalog.Search(images.SearchParams{State: "active"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("Unexpected number of images found. Got %d, expected %d", len(found), 2)
+	}
+
+	regions, err := images.GetRegionsFrom(src, images.APIVersion, "amazon")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(regions) == 0 {
+		t.Fatalf("Expected some regions")
+	}
+
+	for _, r := range srv.Requests() {
+		if !strings.Contains(r, ".xml") {
+			t.Fatalf("Unexpected request of a JSON document %s", r)
+		}
+	}
+}
+
+func TestUnknownProviderAndRegion(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	for _, params := range []images.SearchParams{
+		{APIEndpoint: srv.URL, Cloud: "hpcloud", Region: "eu-central-1", State: "active"},
+		{APIEndpoint: srv.URL, Cloud: "amazon", Region: "mars-north-1", State: "active"},
+	} {
+		if _, err := images.GetImages(params); err == nil {
+			t.Fatalf("An error was expected for %+v", params)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	srv := newTestServer(t, WithStatus("/v1/amazon/eu-central-1/", http.StatusServiceUnavailable))
+	defer srv.Close()
+
+	params := images.SearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		State:       "active",
+	}
+	if _, err := images.GetImages(params); err == nil {
+		t.Fatal("An error was expected because of the status hook")
+	}
+
+	srv.ResetHooks()
+	srv.AddHook(WithMalformedPayload("/v1/amazon/"))
+	if _, err := images.GetImages(params); err == nil {
+		t.Fatal("An error was expected because of the malformed payload")
+	}
+
+	srv.ResetHooks()
+	srv.AddHook(WithLatency(50 * time.Millisecond))
+	start := time.Now()
+	if _, err := images.GetImages(params); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("The reply should have been delayed")
+	}
+}
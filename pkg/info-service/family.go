@@ -0,0 +1,44 @@
+package images
+
+import "sort"
+
+// Family groups the images whose names only differ by their build date, like
+// suse-sles-15-sp1-v20190624-hvm-ssd-x86_64 and
+// suse-sles-15-sp1-v20190301-hvm-ssd-x86_64. See NameInfo.Family.
+type Family struct {
+	// Name is the name of the images without their build date, like
+	// "suse-sles-15-sp1-hvm-ssd-x86_64"
+	Name string
+	// Latest is the most recently published image of the family
+	Latest Image
+	// Count is the number of images of the family
+	Count int
+}
+
+// GroupFamilies groups the images by family, the families are sorted by
+// name. The images of different regions should not be mixed, as their IDs
+// differ.
+func GroupFamilies(found []Image) []Family {
+	sorted := append([]Image{}, found...)
+	sortImages(sorted, false)
+
+	byName := make(map[string]*Family)
+	for _, image := range sorted {
+		name := ParseName(image.Name).Family
+		if f, ok := byName[name]; ok {
+			f.Count++
+			continue
+		}
+		byName[name] = &Family{Name: name, Latest: image, Count: 1}
+	}
+
+	families := make([]Family, 0, len(byName))
+	for _, f := range byName {
+		families = append(families, *f)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+
+	return families
+}
@@ -0,0 +1,34 @@
+package images
+
+import "testing"
+
+func TestGroupFamilies(t *testing.T) {
+	families := GroupFamilies([]Image{
+		{Name: "suse-sles-15-sp1-v20190301-hvm-ssd-x86_64", ID: "ami-1", PublishedOn: "20190301"},
+		{Name: "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64", ID: "ami-2", PublishedOn: "20190624"},
+		{Name: "suse-sles-15-sp1-v20190624-hvm-ssd-arm64", ID: "ami-3", PublishedOn: "20190624"},
+		{Name: "suse-sles-15-sp1-v20180101-hvm-ssd-x86_64", ID: "ami-4", PublishedOn: "20180101"},
+	})
+
+	expected := []struct {
+		name   string
+		latest string
+		count  int
+	}{
+		{"suse-sles-15-sp1-hvm-ssd-arm64", "ami-3", 1},
+		{"suse-sles-15-sp1-hvm-ssd-x86_64", "ami-2", 3},
+	}
+	if len(families) != len(expected) {
+		t.Fatalf("Unexpected number of families. Got %d, expected %d", len(families), len(expected))
+	}
+	for i, e := range expected {
+		f := families[i]
+		if f.Name != e.name || f.Latest.ID != e.latest || f.Count != e.count {
+			t.Fatalf("Unexpected family %+v, expected %+v", f, e)
+		}
+	}
+
+	if families := GroupFamilies(nil); len(families) != 0 {
+		t.Fatalf("Unexpected families %+v", families)
+	}
+}
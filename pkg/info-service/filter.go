@@ -0,0 +1,412 @@
+package images
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter is a compiled filter expression that can be evaluated against
+// images.
+//
+// Filter expressions are written using a small expression language:
+//
+//	product == "sles" && sp >= 4 && license == "payg" && published_on > date("2024-01-01")
+//
+// The following fields are available, the ones marked with (*) are parsed
+// from the name of the image by ParseName:
+//
+//	name, id, state, region,
+//	replacement_name, replacement_id                  string
+//	published_on, deprecated_on, deleted_on           date
+//	product, variant, license, virt, arch, family (*) string
+//	version, sp (*)                                   int
+//	build_date (*)                                    date
+//
+// Values can be compared using ==, !=, <, <=, > and >=, checked for list
+// membership using `in` (for example `arch in ["x86_64", "arm64"]`) and
+// combined using &&, || and !. Strings are quoted with either single or
+// double quotes.
+//
+// The following functions are available:
+//
+//	date(string) date                  "2006-01-02" or "20060102" layout
+//	matches(string, string) bool       regular expression match
+//	contains(string, string) bool
+//	starts_with(string, string) bool
+//	ends_with(string, string) bool
+//	has(field) bool                    the field is set, like has(deprecated_on)
+//
+// The arguments of date and the pattern of matches must be string literals,
+// so that all the errors are reported by CompileFilter.
+//
+// A date that is not set, like the deprecated_on of an image that was never
+// deprecated, is not comparable: all the comparisons involving it are false,
+// except != which is true.
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// filterType is the type of the values handled by filter expressions
+type filterType int
+
+const (
+	filterString filterType = iota
+	filterInt
+	filterBool
+	filterDate
+	filterStringList
+	filterIntList
+)
+
+func (t filterType) String() string {
+	switch t {
+	case filterString:
+		return "string"
+	case filterInt:
+		return "int"
+	case filterBool:
+		return "bool"
+	case filterDate:
+		return "date"
+	case filterStringList:
+		return "list of strings"
+	case filterIntList:
+		return "list of ints"
+	}
+	return "unknown"
+}
+
+// filterEnv holds the values the fields of an expression are resolved
+// against
+type filterEnv struct {
+	image Image
+	info  NameInfo
+}
+
+type filterField struct {
+	typ filterType
+	get func(env *filterEnv) interface{}
+}
+
+var filterFields = map[string]filterField{
+	"name":             {filterString, func(e *filterEnv) interface{} { return e.image.Name }},
+	"id":               {filterString, func(e *filterEnv) interface{} { return e.image.ID }},
+	"state":            {filterString, func(e *filterEnv) interface{} { return e.image.State }},
+	"region":           {filterString, func(e *filterEnv) interface{} { return e.image.Region }},
+	"replacement_name": {filterString, func(e *filterEnv) interface{} { return e.image.ReplacementName }},
+	"replacement_id":   {filterString, func(e *filterEnv) interface{} { return e.image.ReplacementID }},
+	"published_on":     {filterDate, func(e *filterEnv) interface{} { return parseImageDate(e.image.PublishedOn) }},
+	"deprecated_on":    {filterDate, func(e *filterEnv) interface{} { return parseImageDate(e.image.DeprecatedOn) }},
+	"deleted_on":       {filterDate, func(e *filterEnv) interface{} { return parseImageDate(e.image.DeletedOn) }},
+	"product":          {filterString, func(e *filterEnv) interface{} { return e.info.Product }},
+	"variant":          {filterString, func(e *filterEnv) interface{} { return e.info.Variant }},
+	"license":          {filterString, func(e *filterEnv) interface{} { return e.info.License }},
+	"virt":             {filterString, func(e *filterEnv) interface{} { return e.info.Virt }},
+	"arch":             {filterString, func(e *filterEnv) interface{} { return e.info.Arch }},
+	"family":           {filterString, func(e *filterEnv) interface{} { return e.info.Family }},
+	"version":          {filterInt, func(e *filterEnv) interface{} { return int64(e.info.Version) }},
+	"sp":               {filterInt, func(e *filterEnv) interface{} { return int64(e.info.SP) }},
+	"build_date":       {filterDate, func(e *filterEnv) interface{} { return e.info.BuildDate }},
+}
+
+// FilterFields returns the sorted names of the fields available inside of
+// filter expressions
+func FilterFields() []string {
+	fields := make([]string, 0, len(filterFields))
+	for field := range filterFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// parseImageDate parses the dates returned by the API, an empty or invalid
+// date is returned as the zero time
+func parseImageDate(s string) time.Time {
+	t, _ := time.Parse(PublishedOnLayout, s)
+	return t
+}
+
+// CompileFilter parses and type checks a filter expression
+func CompileFilter(expr string) (*Filter, error) {
+	p := filterParser{lexer: filterLexer{input: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	if root.typ() != filterBool {
+		return nil, &FilterError{Expr: expr, Pos: 0,
+			Msg: fmt.Sprintf("expression must be a bool, got %s", root.typ())}
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match returns true when the image satisfies the filter expression
+func (f *Filter) Match(image Image) bool {
+	env := filterEnv{image: image, info: ParseName(image.Name)}
+	return f.root.eval(&env).(bool)
+}
+
+// String returns the source of the filter expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// FilterError describes an error found while compiling a filter expression
+type FilterError struct {
+	Expr string
+	// Pos is the byte offset inside of Expr where the error was found
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter expression at position %d: %s", e.Pos, e.Msg)
+}
+
+// Is reports whether the error matches ErrInvalidFilter
+func (e *FilterError) Is(target error) bool {
+	return target == ErrInvalidFilter
+}
+
+// filterNode is a node of the syntax tree of a filter expression
+type filterNode interface {
+	typ() filterType
+	eval(env *filterEnv) interface{}
+}
+
+type literalNode struct {
+	t filterType
+	v interface{}
+}
+
+func (n *literalNode) typ() filterType                 { return n.t }
+func (n *literalNode) eval(env *filterEnv) interface{} { return n.v }
+
+type fieldNode struct {
+	field filterField
+}
+
+func (n *fieldNode) typ() filterType                 { return n.field.typ }
+func (n *fieldNode) eval(env *filterEnv) interface{} { return n.field.get(env) }
+
+type listNode struct {
+	t     filterType
+	items []filterNode
+}
+
+func (n *listNode) typ() filterType { return n.t }
+func (n *listNode) eval(env *filterEnv) interface{} {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		values = append(values, item.eval(env))
+	}
+	return values
+}
+
+type notNode struct {
+	operand filterNode
+}
+
+func (n *notNode) typ() filterType                 { return filterBool }
+func (n *notNode) eval(env *filterEnv) interface{} { return !n.operand.eval(env).(bool) }
+
+type logicalNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (n *logicalNode) typ() filterType { return filterBool }
+func (n *logicalNode) eval(env *filterEnv) interface{} {
+	left := n.left.eval(env).(bool)
+	if n.op == "&&" {
+		return left && n.right.eval(env).(bool)
+	}
+	return left || n.right.eval(env).(bool)
+}
+
+type compareNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (n *compareNode) typ() filterType { return filterBool }
+func (n *compareNode) eval(env *filterEnv) interface{} {
+	left := n.left.eval(env)
+	right := n.right.eval(env)
+
+	if n.op == "in" {
+		for _, item := range right.([]interface{}) {
+			if compareValues(left, item) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	if missingDate(left) || missingDate(right) {
+		return n.op == "!="
+	}
+
+	c := compareValues(left, right)
+	switch n.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// missingDate tells whether v is a date that is not set
+func missingDate(v interface{}) bool {
+	t, ok := v.(time.Time)
+	return ok && t.IsZero()
+}
+
+// compareValues compares two values of the same type
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case int64:
+		switch b := b.(int64); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+type callNode struct {
+	fn   func(args []interface{}) interface{}
+	args []filterNode
+}
+
+func (n *callNode) typ() filterType { return filterBool }
+func (n *callNode) eval(env *filterEnv) interface{} {
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		args = append(args, arg.eval(env))
+	}
+	return n.fn(args)
+}
+
+// newCall type checks the invocation of a function and returns the node
+// evaluating it
+func (p *filterParser) newCall(name string, pos int, args []filterNode) (filterNode, error) {
+	checkArgs := func(count int) error {
+		if len(args) != count {
+			return p.errorAt(pos, "%s expects %d arguments, got %d", name, count, len(args))
+		}
+		for i, arg := range args {
+			if arg.typ() != filterString {
+				return p.errorAt(pos, "argument %d of %s must be a string, got %s",
+					i+1, name, arg.typ())
+			}
+		}
+		return nil
+	}
+	literal := func(arg filterNode) (string, bool) {
+		l, ok := arg.(*literalNode)
+		if !ok {
+			return "", false
+		}
+		return l.v.(string), true
+	}
+
+	switch name {
+	case "date":
+		if err := checkArgs(1); err != nil {
+			return nil, err
+		}
+		s, ok := literal(args[0])
+		if !ok {
+			return nil, p.errorAt(pos, "the argument of date must be a string literal")
+		}
+		for _, layout := range []string{"2006-01-02", PublishedOnLayout} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return &literalNode{t: filterDate, v: t}, nil
+			}
+		}
+		return nil, p.errorAt(pos, "invalid date %q, expected YYYY-MM-DD", s)
+	case "matches":
+		if err := checkArgs(2); err != nil {
+			return nil, err
+		}
+		pattern, ok := literal(args[1])
+		if !ok {
+			return nil, p.errorAt(pos, "the pattern of matches must be a string literal")
+		}
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorAt(pos, "invalid regular expression %q: %v", pattern, err)
+		}
+		return &callNode{args: args[:1], fn: func(v []interface{}) interface{} {
+			return r.MatchString(v[0].(string))
+		}}, nil
+	case "contains", "starts_with", "ends_with":
+		if err := checkArgs(2); err != nil {
+			return nil, err
+		}
+		fn := map[string]func(string, string) bool{
+			"contains":    strings.Contains,
+			"starts_with": strings.HasPrefix,
+			"ends_with":   strings.HasSuffix,
+		}[name]
+		return &callNode{args: args, fn: func(v []interface{}) interface{} {
+			return fn(v[0].(string), v[1].(string))
+		}}, nil
+	case "has":
+		if len(args) != 1 {
+			return nil, p.errorAt(pos, "%s expects %d arguments, got %d", name, 1, len(args))
+		}
+		if _, ok := args[0].(*fieldNode); !ok {
+			return nil, p.errorAt(pos, "the argument of has must be a field")
+		}
+		return &callNode{args: args, fn: func(v []interface{}) interface{} {
+			switch v := v[0].(type) {
+			case string:
+				return v != ""
+			case time.Time:
+				return !v.IsZero()
+			}
+			return true
+		}}, nil
+	}
+
+	return nil, p.errorAt(pos, "unknown function %q", name)
+}
@@ -0,0 +1,340 @@
+package images
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokInt
+	tokOperator
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+	pos   int
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("string %q", t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// filterLexer splits a filter expression into tokens
+type filterLexer struct {
+	input string
+	pos   int
+}
+
+var filterOperators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",",
+}
+
+func (l *filterLexer) next() (filterToken, *FilterError) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return filterToken{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.input[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		var sb strings.Builder
+		l.pos++
+		for l.pos < len(l.input) && l.input[l.pos] != c {
+			if l.input[l.pos] == '\\' && l.pos+1 < len(l.input) {
+				l.pos++
+			}
+			sb.WriteByte(l.input[l.pos])
+			l.pos++
+		}
+		if l.pos >= len(l.input) {
+			return filterToken{}, &FilterError{Expr: l.input, Pos: start, Msg: "unterminated string"}
+		}
+		l.pos++
+		return filterToken{kind: tokString, value: sb.String(), pos: start}, nil
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.input) && l.input[l.pos] >= '0' && l.input[l.pos] <= '9' {
+			l.pos++
+		}
+		return filterToken{kind: tokInt, value: l.input[start:l.pos], pos: start}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.input) &&
+			(l.input[l.pos] == '_' || unicode.IsLetter(rune(l.input[l.pos])) ||
+				unicode.IsDigit(rune(l.input[l.pos]))) {
+			l.pos++
+		}
+		return filterToken{kind: tokIdent, value: l.input[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range filterOperators {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			return filterToken{kind: tokOperator, value: op, pos: start}, nil
+		}
+	}
+
+	return filterToken{}, &FilterError{Expr: l.input, Pos: start,
+		Msg: fmt.Sprintf("unexpected character %q", c)}
+}
+
+// filterParser is a recursive descent parser of filter expressions, the
+// grammar is:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = primary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) primary ]
+//	primary = string | int | "true" | "false" | field | call | list | "(" or ")"
+//	call    = ident "(" [ or { "," or } ] ")"
+//	list    = "[" [ primary { "," primary } ] "]"
+type filterParser struct {
+	lexer filterLexer
+	tok   filterToken
+}
+
+func (p *filterParser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *filterParser) errorAt(pos int, format string, args ...interface{}) *FilterError {
+	return &FilterError{Expr: p.lexer.input, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) *FilterError {
+	return p.errorAt(p.tok.pos, format, args...)
+}
+
+func (p *filterParser) isOperator(op string) bool {
+	return p.tok.kind == tokOperator && p.tok.value == op
+}
+
+func (p *filterParser) expect(op string) error {
+	if !p.isOperator(op) {
+		return p.errorf("expected %q, got %s", op, p.tok)
+	}
+	return p.next()
+}
+
+func (p *filterParser) parseLogical(op string, operand func() (filterNode, error)) (filterNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(op) {
+		pos := p.tok.pos
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.typ() != filterBool || right.typ() != filterBool {
+			return nil, p.errorAt(pos, "operands of %s must be bool, got %s and %s",
+				op, left.typ(), right.typ())
+		}
+		left = &logicalNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	return p.parseLogical("&&", p.parseUnary)
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if !p.isOperator("!") {
+		return p.parseCompare()
+	}
+
+	pos := p.tok.pos
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.typ() != filterBool {
+		return nil, p.errorAt(pos, "operand of ! must be bool, got %s", operand.typ())
+	}
+
+	return &notNode{operand: operand}, nil
+}
+
+func (p *filterParser) parseCompare() (filterNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	var op string
+	switch {
+	case p.tok.kind == tokOperator &&
+		strings.Contains(" == != < <= > >= ", " "+p.tok.value+" "):
+		op = p.tok.value
+	case p.tok.kind == tokIdent && p.tok.value == "in":
+		op = "in"
+	default:
+		return left, nil
+	}
+
+	pos := p.tok.pos
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case op == "in":
+		if !(left.typ() == filterString && right.typ() == filterStringList ||
+			left.typ() == filterInt && right.typ() == filterIntList) {
+			return nil, p.errorAt(pos, "cannot check if %s is in %s", left.typ(), right.typ())
+		}
+	case left.typ() != right.typ():
+		return nil, p.errorAt(pos, "cannot compare %s with %s", left.typ(), right.typ())
+	case left.typ() == filterStringList || left.typ() == filterIntList:
+		return nil, p.errorAt(pos, "cannot compare lists")
+	case left.typ() == filterBool && op != "==" && op != "!=":
+		return nil, p.errorAt(pos, "bool values can only be compared using == and !=")
+	}
+
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.tok
+
+	switch {
+	case tok.kind == tokString:
+		return &literalNode{t: filterString, v: tok.value}, p.next()
+	case tok.kind == tokInt:
+		v, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", tok.value)
+		}
+		return &literalNode{t: filterInt, v: v}, p.next()
+	case tok.kind == tokIdent && (tok.value == "true" || tok.value == "false"):
+		return &literalNode{t: filterBool, v: tok.value == "true"}, p.next()
+	case tok.kind == tokIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.isOperator("(") {
+			return p.parseCall(tok)
+		}
+		field, ok := filterFields[tok.value]
+		if !ok {
+			return nil, p.errorAt(tok.pos, "unknown field %q, valid fields are: %s",
+				tok.value, strings.Join(FilterFields(), ", "))
+		}
+		return &fieldNode{field: field}, nil
+	case p.isOperator("("):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	case p.isOperator("["):
+		return p.parseList()
+	}
+
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+func (p *filterParser) parseCall(name filterToken) (filterNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	args := []filterNode{}
+	for !p.isOperator(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	return p.newCall(name.value, name.pos, args)
+}
+
+func (p *filterParser) parseList() (filterNode, error) {
+	pos := p.tok.pos
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	list := &listNode{t: filterStringList}
+	for !p.isOperator("]") {
+		if len(list.items) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		item, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	for i, item := range list.items {
+		switch {
+		case i == 0 && item.typ() == filterInt:
+			list.t = filterIntList
+		case item.typ() == filterString && list.t == filterStringList:
+		case item.typ() == filterInt && list.t == filterIntList:
+		default:
+			return nil, p.errorAt(pos, "lists can only hold strings or ints of the same type")
+		}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,99 @@
+package images
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	image := Image{
+		Name:         "suse-sles-sap-15-sp4-v20240115-hvm-ssd-x86_64",
+		State:        "active",
+		PublishedOn:  "20240115",
+		DeprecatedOn: "",
+		Region:       "eu-central-1",
+		ID:           "ami-0123456789",
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`product == "sles" && sp >= 4 && license == "payg" && published_on > date("2024-01-01")`, true},
+		{`product == "sles" && sp >= 5`, false},
+		{`variant == 'sap' && version == 15`, true},
+		{`arch in ["x86_64", "arm64"] && !(virt == "pv")`, true},
+		{`sp in [1, 2, 3]`, false},
+		{`matches(name, "^suse-sles-sap-15") || state == "deprecated"`, true},
+		{`starts_with(region, "us-") || ends_with(id, "789")`, true},
+		{`contains(family, "v20240115")`, false},
+		{`build_date == date("20240115")`, true},
+		{`deprecated_on < date("2024-01-01")`, false},
+		{`deprecated_on >= date("2024-01-01")`, false},
+		{`deprecated_on == date("2024-01-01")`, false},
+		{`deprecated_on != date("2024-01-01")`, true},
+		{`!has(deprecated_on) && has(published_on)`, true},
+		{`has(replacement_name)`, false},
+	}
+
+	for _, test := range tests {
+		filter, err := CompileFilter(test.expr)
+		if err != nil {
+			t.Fatalf("Unexpected error compiling %s: %v", test.expr, err)
+		}
+		if filter.Match(image) != test.expected {
+			t.Errorf("Unexpected result of %s. Got %v, expected %v",
+				test.expr, !test.expected, test.expected)
+		}
+	}
+	// the Microsoft Azure names state the arm64 architecture only
+	filter, err := CompileFilter(`arch == "x86_64"`)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !filter.Match(Image{Name: "suse-sles-15-sp1-v20190624"}) {
+		t.Errorf("The Microsoft Azure image should be x86_64")
+	}
+	if filter.Match(Image{Name: "suse-sles-15-sp1-arm64-v20190624"}) {
+		t.Errorf("The Microsoft Azure image should be arm64")
+	}
+}
+
+func TestFilterCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{`product == `, 11},
+		{`products == "sles"`, 0},
+		{`sp >= "4"`, 3},
+		{`product`, 0},
+		{`sp > 4 &&`, 9},
+		{`published_on > date("yesterday")`, 15},
+		{`matches(name, "[")`, 0},
+		{`foo(name)`, 0},
+		{`name == "sles`, 8},
+		{`name # "sles"`, 5},
+		{`(sp > 4`, 7},
+		{`arch in ["x86_64", 1]`, 8},
+		{`has("sles")`, 0},
+	}
+
+	for _, test := range tests {
+		_, err := CompileFilter(test.expr)
+		if err == nil {
+			t.Errorf("Compilation of %s should have failed", test.expr)
+			continue
+		}
+
+		var filterErr *FilterError
+		if !errors.As(err, &filterErr) {
+			t.Errorf("Unexpected error type %T for %s", err, test.expr)
+			continue
+		}
+		if filterErr.Pos != test.pos {
+			t.Errorf("Unexpected error position for %s. Got %d, expected %d (%v)",
+				test.expr, filterErr.Pos, test.pos, err)
+		}
+	}
+}
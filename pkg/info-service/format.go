@@ -0,0 +1,63 @@
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is the format of the documents served by the info service
+type Format string
+
+// The formats of the documents
+const (
+	FormatJSON Format = "json"
+	// FormatXML documents use one element per item and one attribute per
+	// field:
+	//
+	//	<images>
+	//	  <image name="suse-sles-15-sp1-v20190624-hvm-ssd-x86_64" state="active" id="ami-0352b14942c00b04b" .../>
+	//	</images>
+	FormatXML Format = "xml"
+)
+
+// ValidFormats holds the formats supported by the package
+var ValidFormats = []string{string(FormatJSON), string(FormatXML)}
+
+// ValidateFormat raises an error if the specified format is not a valid one
+func ValidateFormat(format string) error {
+	for _, vf := range ValidFormats {
+		if format == vf {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid format: %s", format)
+}
+
+// documentPath returns the path of the document in the given format. The
+// paths used by the package are the ones of the JSON documents, like
+// "v1/amazon/regions.json" or "v1/amazon/dataversion".
+func documentPath(p string, format Format) string {
+	if format == "" || format == FormatJSON {
+		return p
+	}
+	return strings.TrimSuffix(p, ".json") + "." + string(format)
+}
+
+// decodeDocument decodes a JSON or an XML document into v, the format is
+// detected from the content of the document
+func decodeDocument(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		return xml.Unmarshal(trimmed, v)
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,97 @@
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	for _, doc := range []string{
+		`{"images": [{"name": "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64", "state": "active", "id": "ami-0352b14942c00b04b", "region": "eu-central-1"}]}`,
+		`<?xml version="1.0"?>
+<images>
+  <image name="suse-sles-15-sp1-v20190624-hvm-ssd-x86_64" state="active" id="ami-0352b14942c00b04b" region="eu-central-1"/>
+</images>`,
+	} {
+		var reply imagesReply
+		if err := decodeDocument(strings.NewReader(doc), &reply); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(reply.Images) != 1 {
+			t.Fatalf("Unexpected number of images. Got %d, expected %d", len(reply.Images), 1)
+		}
+		image := reply.Images[0]
+		if image.ID != "ami-0352b14942c00b04b" || image.State != "active" || image.Region != "eu-central-1" {
+			t.Fatalf("Unexpected image %+v", image)
+		}
+	}
+
+	var version dataVersionReply
+	if err := decodeDocument(strings.NewReader(`<dataversion version="20190624"/>`), &version); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if version.XMLVersion != "20190624" {
+		t.Fatalf("Unexpected version. Got %s, expected %s", version.XMLVersion, "20190624")
+	}
+}
+
+func TestDocumentPath(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		format   Format
+		expected string
+	}{
+		{"v1/amazon/images.json", "", "v1/amazon/images.json"},
+		{"v1/amazon/images.json", FormatJSON, "v1/amazon/images.json"},
+		{"v1/amazon/images.json", FormatXML, "v1/amazon/images.xml"},
+		{"v1/amazon/dataversion", FormatXML, "v1/amazon/dataversion.xml"},
+	} {
+		if p := documentPath(test.path, test.format); p != test.expected {
+			t.Fatalf("Unexpected path. Got %s, expected %s", p, test.expected)
+		}
+	}
+
+	if err := ValidateFormat("yaml"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestEndpointPathPrefix(t *testing.T) {
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		if strings.HasSuffix(r.URL.Path, ".xml") {
+			_, _ = w.Write([]byte(`<regions><region name="East US 2"/></regions>`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"regions": [{"name": "East US 2"}]}`))
+	}))
+	defer srv.Close()
+
+	for _, test := range []struct {
+		endpoint string
+		format   Format
+		expected string
+	}{
+		{srv.URL, FormatJSON, "/v1/microsoft/regions.json"},
+		{srv.URL + "/", FormatJSON, "/v1/microsoft/regions.json"},
+		{srv.URL + "/mirror/susepubliccloud", FormatJSON, "/mirror/susepubliccloud/v1/microsoft/regions.json"},
+		{srv.URL + "/mirror/susepubliccloud/?key=value", FormatXML, "/mirror/susepubliccloud/v1/microsoft/regions.xml"},
+		{srv.URL + "/mirror%20one", FormatJSON, "/mirror%20one/v1/microsoft/regions.json"},
+	} {
+		requested = nil
+		src := &HTTPSource{Endpoint: test.endpoint, Format: test.format}
+		regions, err := GetRegionsFrom(src, APIVersion, "microsoft")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(regions) != 1 || regions[0] != "East US 2" {
+			t.Fatalf("Unexpected regions %v", regions)
+		}
+		if len(requested) != 1 || requested[0] != test.expected {
+			t.Fatalf("Unexpected requests for %s. Got %v, expected %s", test.endpoint, requested, test.expected)
+		}
+	}
+}
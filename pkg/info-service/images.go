@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
-	"net/url"
-	"path/filepath"
-	"regexp"
+	"path"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -27,33 +25,45 @@ import (
 //	  "deletedon": ""
 //	},
 type Image struct {
-	Name            string `json:"name"`
-	State           string `jsong:"state"`
-	ReplacementName string `json:"replacementname,omitempty"`
-	ReplacementID   string `json:"replacementid,omitempty"`
-	PublishedOn     string `json:"publishedon"`
-	DeprecatedOn    string `json:"deprecatedon,omitempty"`
-	Region          string `json:"region"`
-	ID              string `json:"id"`
-	DeletedOn       string `json:"deletedon,omitempty"`
+	Name            string `json:"name" xml:"name,attr"`
+	State           string `json:"state" xml:"state,attr"`
+	ReplacementName string `json:"replacementname,omitempty" xml:"replacementname,attr,omitempty"`
+	ReplacementID   string `json:"replacementid,omitempty" xml:"replacementid,attr,omitempty"`
+	PublishedOn     string `json:"publishedon" xml:"publishedon,attr"`
+	DeprecatedOn    string `json:"deprecatedon,omitempty" xml:"deprecatedon,attr,omitempty"`
+	Region          string `json:"region" xml:"region,attr"`
+	ID              string `json:"id" xml:"id,attr"`
+	DeletedOn       string `json:"deletedon,omitempty" xml:"deletedon,attr,omitempty"`
+	// Extra holds the fields of the documents not mapped by the other
+	// fields, like the "urn" of the Microsoft Azure images. See extra.go.
+	Extra map[string]interface{} `json:"-" xml:"-"`
+	// Raw holds the JSON document the image has been decoded from, nil
+	// when it has been decoded from XML or built in memory. See RawJSON.
+	Raw json.RawMessage `json:"-" xml:"-"`
 }
 
 // Internally used to parse the response from
 // SUSE public cloud info service API
 type imagesReply struct {
-	Images []Image `json:"images"`
+	Images []Image `json:"images" xml:"image"`
 }
 
 // SearchParams is used to describe the search criteria to find one or more
 // images
 type SearchParams struct {
-	APIEndpoint   string
-	APIVersion    string
-	Cloud         string
+	APIEndpoint string
+	APIVersion  string
+	Cloud       string
+	// Filter is an optional filter expression, see Filter
+	Filter        string
 	NameRegex     string
 	Region        string
 	SortAscending bool
 	State         string
+
+	// Source provides the documents of the API, when nil they are fetched
+	// from APIEndpoint
+	Source Source
 }
 
 // APIEndpoint is the endoint of the public instance of
@@ -79,76 +89,115 @@ func GetImages(params SearchParams) ([]Image, error) {
 	if err := ValidateState(params.State); err != nil {
 		return images, err
 	}
-
-	if params.APIEndpoint == "" {
-		params.APIEndpoint = APIEndpoint
-	}
-
-	if params.APIVersion == "" {
-		params.APIVersion = APIVersion
-	}
-
-	baseURL, err := url.Parse(params.APIEndpoint)
-	if err != nil {
+	if err := validatePathElements(params.Cloud, params.Region); err != nil {
 		return images, err
 	}
 
-	urlPath := filepath.Join(
+	p := apiPath(
 		params.APIVersion,
 		params.Cloud,
 		params.Region,
 		"images",
 		fmt.Sprintf("%s.json", params.State))
-	relURL, err := baseURL.Parse(urlPath)
-	if err != nil {
-		return images, err
-	}
 
-	resp, err := http.Get(relURL.String())
-	if err != nil {
-		return images,
-			fmt.Errorf("error while accessing %v: %v", relURL, err)
+	var reply imagesReply
+	src := sourceFor(params.Source, params.APIEndpoint)
+	if err := getDocument(src, p, "", &reply); err != nil {
+		return images, classifyNotFound(err, src, params.APIVersion, params.Cloud, params.Region)
 	}
-	defer func() {
-		if e := resp.Body.Close(); e != nil {
-			log.Printf("failed to close response body: %v", e)
-		}
-	}()
 
-	if resp.StatusCode != 200 {
-		return images,
-			fmt.Errorf("unexpected HTTP status %d while accessing %v",
-				resp.StatusCode, relURL)
+	return filterImages(reply.Images, params)
+}
+
+// GetAllImages returns all the images of the cloud framework, or of the
+// region when set, whatever their state. A single document is fetched, the
+// name regex, the filter and the state of the search criteria are ignored.
+func GetAllImages(params SearchParams) ([]Image, error) {
+	if err := validatePathElements(params.Cloud, params.Region); err != nil {
+		return []Image{}, err
 	}
 
 	var reply imagesReply
-	if err = json.NewDecoder(resp.Body).Decode(&reply); err != nil {
-		return images,
-			fmt.Errorf("error while decoding remote response from %s: %v",
-				relURL, err)
+	p := apiPath(params.APIVersion, params.Cloud, params.Region, "images.json")
+	src := sourceFor(params.Source, params.APIEndpoint)
+	if err := getDocument(src, p, "", &reply); err != nil {
+		return []Image{}, classifyNotFound(err, src, params.APIVersion, params.Cloud, params.Region)
 	}
 
-	if params.NameRegex != "" {
-		r := regexp.MustCompile(params.NameRegex)
-		for _, image := range reply.Images {
-			if r.MatchString(image.Name) {
-				images = append(images, image)
-			}
+	return reply.Images, nil
+}
+
+// filterImages returns the images matching the name regex and the filter
+// expression of the search criteria, sorted by publication time
+func filterImages(candidates []Image, params SearchParams) ([]Image, error) {
+	images := make([]Image, 0)
+
+	m, err := newImageMatcher(params)
+	if err != nil {
+		return images, err
+	}
+
+	for _, image := range candidates {
+		if criterion, _ := m.reject(image); criterion == "" {
+			images = append(images, image)
 		}
-	} else {
-		images = reply.Images[:]
 	}
+	sortImages(images, params.SortAscending)
+
+	return images, nil
+}
 
-	sort.Slice(images, func(i, j int) bool {
-		itime, _ := time.Parse("20060102", images[i].PublishedOn)
-		jtime, _ := time.Parse("20060102", images[j].PublishedOn)
-		if params.SortAscending {
+// sortImages sorts the images by publication time, the newest first unless
+// ascending is set
+func sortImages(images []Image, ascending bool) {
+	sort.SliceStable(images, func(i, j int) bool {
+		itime, _ := time.Parse(PublishedOnLayout, images[i].PublishedOn)
+		jtime, _ := time.Parse(PublishedOnLayout, images[j].PublishedOn)
+		if ascending {
 			return itime.Unix() < jtime.Unix()
 		}
 		return itime.Unix() > jtime.Unix()
 	})
+}
 
-	return images, nil
+// apiPath builds the path of an API resource, relative to the root of the
+// API, by appending the path elements to the API version
+func apiPath(version string, elem ...string) string {
+	if version == "" {
+		version = APIVersion
+	}
+
+	return path.Join(append([]string{version}, elem...)...)
+}
+
+// sourceFor returns the source of the API documents, src when set or the
+// info service found at endpoint otherwise
+func sourceFor(src Source, endpoint string) Source {
+	if src != nil {
+		return src
+	}
+	return NewHTTPSource(endpoint)
+}
+
+// getDocument fetches the document found at the given path of the source
+// and decodes it into v, see decodeDocument
+func getDocument(src Source, p, query string, v interface{}) error {
+	body, err := src.Open(p, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := body.Close(); e != nil {
+			log.Printf("failed to close response body: %v", e)
+		}
+	}()
+
+	if err := decodeDocument(body, v); err != nil {
+		return fmt.Errorf("%w from %s/%s: %v",
+			ErrDecode, strings.TrimSuffix(src.String(), "/"), p, err)
+	}
+
+	return nil
 }
 
 // ValidateState raises an error if the specified image state is not a valid one
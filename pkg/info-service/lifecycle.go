@@ -0,0 +1,127 @@
+package images
+
+import (
+	_ "embed" // the lifecycle table is embedded
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LifecycleDateLayout is the layout of the dates of the lifecycle table,
+// like "2024-01-31"
+const LifecycleDateLayout = "2006-01-02"
+
+// Lifecycle holds the support dates of a service pack of a product
+type Lifecycle struct {
+	// Product, Version and SP are matched against the ones parsed from the
+	// image names, see ParseName
+	Product string `json:"product"`
+	// Variant, when set, restricts the entry to the images of the variant,
+	// like "sap". The entries without a variant match all the variants not
+	// listed by another entry, for the general support only: LTSS is not
+	// offered for the variants, like SLES for SAP Applications.
+	Variant string `json:"variant,omitempty"`
+	Version int    `json:"version"`
+	SP      int    `json:"sp"`
+	// GeneralSupportEnds is the last day of the general support, see
+	// LifecycleDateLayout
+	GeneralSupportEnds string `json:"general_support_ends"`
+	// LTSSEnds is the last day of the Long Term Service Pack Support, empty
+	// when LTSS is not offered
+	LTSSEnds string `json:"ltss_ends,omitempty"`
+}
+
+func (l Lifecycle) String() string {
+	name := l.Product
+	if l.Variant != "" {
+		name += "-" + l.Variant
+	}
+	if l.SP == 0 {
+		return fmt.Sprintf("%s %d", name, l.Version)
+	}
+	return fmt.Sprintf("%s %d SP%d", name, l.Version, l.SP)
+}
+
+// lifecycleTable is the document embedding the lifecycle of the products
+type lifecycleTable struct {
+	Version  string      `json:"version"`
+	Source   string      `json:"source"`
+	Products []Lifecycle `json:"products"`
+}
+
+//go:embed lifecycle.json
+var lifecycleData []byte
+
+var lifecycles = func() lifecycleTable {
+	var table lifecycleTable
+	if err := json.Unmarshal(lifecycleData, &table); err != nil {
+		panic(fmt.Sprintf("invalid lifecycle table: %v", err))
+	}
+	return table
+}()
+
+// LifecycleTableVersion returns the version of the embedded lifecycle
+// table, the date of its last update
+func LifecycleTableVersion() string {
+	return lifecycles.Version
+}
+
+// Lifecycles returns all the entries of the embedded lifecycle table, sorted
+// by product, variant, version and service pack
+func Lifecycles() []Lifecycle {
+	res := append([]Lifecycle{}, lifecycles.Products...)
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		switch {
+		case a.Product != b.Product:
+			return a.Product < b.Product
+		case a.Variant != b.Variant:
+			return a.Variant < b.Variant
+		case a.Version != b.Version:
+			return a.Version < b.Version
+		}
+		return a.SP < b.SP
+	})
+	return res
+}
+
+// LookupLifecycle returns the lifecycle of the service pack of the product.
+// The entry of the variant is preferred, the one of the product is returned
+// otherwise without its LTSS end: the variants share the general support
+// of the product but LTSS is offered for the product only.
+func LookupLifecycle(product, variant string, version, sp int) (Lifecycle, bool) {
+	var found *Lifecycle
+	for i, l := range lifecycles.Products {
+		if !strings.EqualFold(l.Product, product) || l.Version != version || l.SP != sp {
+			continue
+		}
+		switch {
+		case strings.EqualFold(l.Variant, variant):
+			return l, true
+		case l.Variant == "":
+			found = &lifecycles.Products[i]
+		}
+	}
+
+	if found == nil {
+		return Lifecycle{}, false
+	}
+	res := *found
+	if variant != "" {
+		res.Variant = variant
+		res.LTSSEnds = ""
+	}
+	return res, true
+}
+
+// ImageLifecycle returns the lifecycle of the product of the image, parsed
+// from its name. Nothing is returned for the images whose product or
+// version cannot be parsed.
+func ImageLifecycle(image Image) (Lifecycle, bool) {
+	info := ParseName(image.Name)
+	if info.Product == "" || info.Version == 0 {
+		return Lifecycle{}, false
+	}
+	return LookupLifecycle(info.Product, info.Variant, info.Version, info.SP)
+}
@@ -0,0 +1,67 @@
+package images
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLifecycleTable(t *testing.T) {
+	if _, err := time.Parse(LifecycleDateLayout, LifecycleTableVersion()); err != nil {
+		t.Fatalf("Unexpected table version %q: %v", LifecycleTableVersion(), err)
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range Lifecycles() {
+		if seen[l.String()] {
+			t.Fatalf("Duplicated entry %s", l)
+		}
+		seen[l.String()] = true
+
+		general, err := time.Parse(LifecycleDateLayout, l.GeneralSupportEnds)
+		if err != nil {
+			t.Fatalf("Unexpected general support end of %s: %v", l, err)
+		}
+		if l.LTSSEnds == "" {
+			continue
+		}
+		ltss, err := time.Parse(LifecycleDateLayout, l.LTSSEnds)
+		if err != nil {
+			t.Fatalf("Unexpected LTSS end of %s: %v", l, err)
+		}
+		if !ltss.After(general) {
+			t.Fatalf("The LTSS of %s ends before its general support", l)
+		}
+	}
+}
+
+func TestImageLifecycle(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		found   bool
+		general string
+		ltss    string
+	}{
+		{"suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64", true, "2021-01-31", "2024-01-31"},
+		// the variants don't inherit the LTSS of the product
+		{"suse-sles-sap-15-sp1-byos-v20190624-hvm-ssd-x86_64", true, "2021-01-31", ""},
+		{"suse-sles-12-sp4-v20190623-hvm-ssd-x86_64", true, "2020-06-30", "2023-06-30"},
+		{"sles-15-v20180701-hvm-ssd-x86_64", true, "2019-12-31", "2023-12-31"},
+		{"suse-manager-4-0-proxy-byos-v20190725-hvm-ssd-x86_64", false, "", ""},
+		{"suse-sles-9-sp4-v20070101-hvm-ssd-x86_64", false, "", ""},
+		{"custom-image", false, "", ""},
+	} {
+		l, found := ImageLifecycle(Image{Name: test.name})
+		if found != test.found || l.GeneralSupportEnds != test.general || l.LTSSEnds != test.ltss {
+			t.Fatalf("Unexpected lifecycle of %s. Got %+v %v, expected %s %s %v",
+				test.name, l, found, test.general, test.ltss, test.found)
+		}
+	}
+
+	if _, found := LookupLifecycle("SLES", "", 15, 5); !found {
+		t.Fatalf("Expected the lifecycle of SLES 15 SP5")
+	}
+	l, found := LookupLifecycle("sles", "sap", 15, 5)
+	if !found || l.Variant != "sap" || l.GeneralSupportEnds != "2024-12-31" || l.LTSSEnds != "" {
+		t.Fatalf("Unexpected lifecycle of SLES for SAP 15 SP5 %+v %v", l, found)
+	}
+}
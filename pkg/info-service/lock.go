@@ -0,0 +1,273 @@
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LockFileVersion is the version of the format of the lock files written by
+// this package
+const LockFileVersion = 1
+
+// LockQuery is the canonical form of an image query recorded inside of a
+// lock file. The endpoint and the version of the API are not part of it, so
+// that the same lock file can be used against mirrors of the info service.
+type LockQuery struct {
+	Cloud         string `json:"cloud"`
+	Region        string `json:"region"`
+	State         string `json:"state"`
+	NameRegex     string `json:"name_regex,omitempty"`
+	Filter        string `json:"filter,omitempty"`
+	SortAscending bool   `json:"sort_ascending"`
+}
+
+// NewLockQuery returns the canonical form of the search criteria
+func NewLockQuery(params SearchParams) LockQuery {
+	q := LockQuery{
+		Cloud:         params.Cloud,
+		Region:        params.Region,
+		State:         params.State,
+		NameRegex:     params.NameRegex,
+		Filter:        params.Filter,
+		SortAscending: params.SortAscending,
+	}
+	if q.State == "" {
+		q.State = "active"
+	}
+
+	return q
+}
+
+// Hash returns the key of the query inside of a lock file
+func (q LockQuery) Hash() string {
+	// the fields are always encoded in the same order
+	b, _ := json.Marshal(q)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// SearchParams returns the search criteria of the query
+func (q LockQuery) SearchParams() SearchParams {
+	return SearchParams{
+		Cloud:         q.Cloud,
+		Region:        q.Region,
+		State:         q.State,
+		NameRegex:     q.NameRegex,
+		Filter:        q.Filter,
+		SortAscending: q.SortAscending,
+	}
+}
+
+// LockEntry is the result of a query recorded inside of a lock file
+type LockEntry struct {
+	Query      LockQuery `json:"query"`
+	IDs        []string  `json:"ids"`
+	ResolvedAt string    `json:"resolved_at"`
+}
+
+// LockChange describes an entry of a lock file whose result changed
+type LockChange struct {
+	Hash  string
+	Query LockQuery
+	Old   []string
+	New   []string
+}
+
+// LockFile records the first resolution of image queries, so that later
+// resolutions return the same images until the entries are explicitly
+// refreshed.
+//
+// A LockFile is safe for concurrent use.
+type LockFile struct {
+	// Path is the location of the lock file on disk
+	Path string
+
+	mu      sync.Mutex
+	entries map[string]LockEntry
+}
+
+// Internally used to encode and decode lock files
+type lockFileContent struct {
+	Version int                  `json:"version"`
+	Entries map[string]LockEntry `json:"entries"`
+}
+
+// LoadLockFile reads the lock file at the given path, an empty lock file is
+// returned when the file does not exist yet
+func LoadLockFile(path string) (*LockFile, error) {
+	l := &LockFile{Path: path, entries: make(map[string]LockEntry)}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while reading lock file %s: %v", path, err)
+	}
+
+	var content lockFileContent
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, fmt.Errorf("error while decoding lock file %s: %v", path, err)
+	}
+	if content.Version != LockFileVersion {
+		return nil, fmt.Errorf("unsupported version %d of lock file %s, expected %d",
+			content.Version, path, LockFileVersion)
+	}
+	for hash, entry := range content.Entries {
+		l.entries[hash] = entry
+	}
+
+	return l, nil
+}
+
+// Lookup returns the image IDs recorded for the query
+func (l *LockFile) Lookup(q LockQuery) ([]string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[q.Hash()]
+	return entry.IDs, ok
+}
+
+// Set records the image IDs of the query. It returns the change of the
+// entry, nil when the recorded IDs are the same.
+func (l *LockFile) Set(q LockQuery, ids []string) *LockChange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.set(q, ids)
+}
+
+func (l *LockFile) set(q LockQuery, ids []string) *LockChange {
+	if ids == nil {
+		ids = []string{}
+	}
+
+	hash := q.Hash()
+	old, ok := l.entries[hash]
+	if ok && equalIDs(old.IDs, ids) {
+		return nil
+	}
+
+	l.entries[hash] = LockEntry{
+		Query:      q,
+		IDs:        ids,
+		ResolvedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	return &LockChange{Hash: hash, Query: q, Old: old.IDs, New: ids}
+}
+
+// Entries returns the recorded entries sorted by hash
+func (l *LockFile) Entries() []LockEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hashes := make([]string, 0, len(l.entries))
+	for hash := range l.entries {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	entries := make([]LockEntry, 0, len(hashes))
+	for _, hash := range hashes {
+		entries = append(entries, l.entries[hash])
+	}
+
+	return entries
+}
+
+// Refresh resolves again all the recorded queries using resolve and returns
+// the entries whose result changed. The entries are updated only when
+// dryRun is false; the lock file is not saved.
+func (l *LockFile) Refresh(resolve func(LockQuery) ([]string, error), dryRun bool) ([]LockChange, error) {
+	changes := make([]LockChange, 0)
+
+	for _, entry := range l.Entries() {
+		ids, err := resolve(entry.Query)
+		if err != nil {
+			return nil, fmt.Errorf("error while refreshing query %+v: %v", entry.Query, err)
+		}
+		if equalIDs(entry.IDs, ids) {
+			continue
+		}
+
+		if dryRun {
+			changes = append(changes, LockChange{
+				Hash: entry.Query.Hash(), Query: entry.Query, Old: entry.IDs, New: ids})
+			continue
+		}
+		l.mu.Lock()
+		change := l.set(entry.Query, ids)
+		l.mu.Unlock()
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	return changes, nil
+}
+
+// Save writes the lock file to Path. The file is replaced atomically.
+func (l *LockFile) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// map keys are sorted by encoding/json, the output is stable
+	b, err := json.MarshalIndent(lockFileContent{Version: LockFileVersion, Entries: l.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.Path), "."+filepath.Base(l.Path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error while writing lock file %s: %v", l.Path, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error while writing lock file %s: %v", l.Path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error while writing lock file %s: %v", l.Path, err)
+	}
+	if err := os.Rename(tmp.Name(), l.Path); err != nil {
+		return fmt.Errorf("error while writing lock file %s: %v", l.Path, err)
+	}
+
+	return nil
+}
+
+// ImageIDs returns the IDs of the images
+func ImageIDs(found []Image) []string {
+	ids := make([]string, 0, len(found))
+	for _, image := range found {
+		ids = append(ids, image.ID)
+	}
+
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+package images
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockQueryHash(t *testing.T) {
+	params := SearchParams{
+		APIEndpoint: "https://example.com",
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		NameRegex:   "suse-sles-15",
+	}
+
+	// the default state and the endpoint are not part of the key
+	same := params
+	same.APIEndpoint = ""
+	same.State = "active"
+	if NewLockQuery(params).Hash() != NewLockQuery(same).Hash() {
+		t.Fatalf("Equivalent queries should have the same hash")
+	}
+
+	for _, different := range []SearchParams{
+		{Cloud: "amazon", Region: "eu-central-1", NameRegex: "suse-sles-12"},
+		{Cloud: "amazon", Region: "eu-central-1", NameRegex: "suse-sles-15", SortAscending: true},
+		{Cloud: "amazon", Region: "eu-central-1", NameRegex: "suse-sles-15", State: "inactive"},
+		{Cloud: "amazon", Region: "eu-central-1", NameRegex: "suse-sles-15", Filter: "sp == 1"},
+	} {
+		if NewLockQuery(params).Hash() == NewLockQuery(different).Hash() {
+			t.Errorf("Query %+v should have a different hash", different)
+		}
+	}
+}
+
+func TestLockFileSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "images.lock.json")
+
+	lockFile, err := LoadLockFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	q := NewLockQuery(SearchParams{Cloud: "amazon", Region: "eu-central-1"})
+	if _, ok := lockFile.Lookup(q); ok {
+		t.Fatalf("A missing lock file should be empty")
+	}
+
+	if change := lockFile.Set(q, []string{"ami-1", "ami-2"}); change == nil || change.Old != nil {
+		t.Fatalf("Unexpected change %+v", change)
+	}
+	if change := lockFile.Set(q, []string{"ami-1", "ami-2"}); change != nil {
+		t.Fatalf("Setting the same IDs should not change the entry, got %+v", change)
+	}
+	if err := lockFile.Save(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lockFile, err = LoadLockFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	ids, ok := lockFile.Lookup(q)
+	if !ok || len(ids) != 2 || ids[0] != "ami-1" || ids[1] != "ami-2" {
+		t.Fatalf("Unexpected locked IDs. Got %v, expected [ami-1 ami-2]", ids)
+	}
+}
+
+func TestLoadLockFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"invalid.json": `{"version": `,
+		"version.json": `{"version": 42, "entries": {}}`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := LoadLockFile(path); err == nil {
+			t.Errorf("Loading %s should have failed", name)
+		}
+	}
+}
+
+func TestLockFileRefresh(t *testing.T) {
+	lockFile, err := LoadLockFile(filepath.Join(t.TempDir(), "images.lock.json"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	stable := NewLockQuery(SearchParams{Cloud: "amazon", Region: "eu-central-1", NameRegex: "stable"})
+	moving := NewLockQuery(SearchParams{Cloud: "amazon", Region: "eu-central-1", NameRegex: "moving"})
+	lockFile.Set(stable, []string{"ami-1"})
+	lockFile.Set(moving, []string{"ami-2"})
+
+	resolve := func(q LockQuery) ([]string, error) {
+		if q.NameRegex == "moving" {
+			return []string{"ami-3", "ami-2"}, nil
+		}
+		return []string{"ami-1"}, nil
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		changes, err := lockFile.Refresh(resolve, dryRun)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(changes) != 1 {
+			t.Fatalf("Unexpected number of changes. Got %d, expected %d", len(changes), 1)
+		}
+		if changes[0].Query != moving || fmt.Sprint(changes[0].Old) != "[ami-2]" ||
+			fmt.Sprint(changes[0].New) != "[ami-3 ami-2]" {
+			t.Fatalf("Unexpected change %+v", changes[0])
+		}
+	}
+
+	ids, _ := lockFile.Lookup(moving)
+	if fmt.Sprint(ids) != "[ami-3 ami-2]" {
+		t.Fatalf("Unexpected locked IDs. Got %v, expected [ami-3 ami-2]", ids)
+	}
+
+	changes, err := lockFile.Refresh(resolve, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Unexpected number of changes. Got %d, expected %d", len(changes), 0)
+	}
+
+	_, err = lockFile.Refresh(func(LockQuery) ([]string, error) {
+		return nil, fmt.Errorf("boom")
+	}, false)
+	if err == nil {
+		t.Fatalf("Errors of resolve should be reported")
+	}
+}
@@ -0,0 +1,443 @@
+// Package mirror implements a self-hosted mirror of the SUSE public cloud
+// info service.
+//
+// A Mirror periodically copies the documents of the selected cloud
+// frameworks from the upstream service into a local directory, and serves
+// them over HTTP using the same /v1/... paths of the upstream API:
+//
+//	m := mirror.New(images.NewHTTPSource(images.APIEndpoint), "/srv/mirror")
+//	m.Providers = []string{"amazon", "microsoft"}
+//	go m.Run(time.Hour, stop)
+//	log.Fatal(http.ListenAndServe(":8080", m))
+//
+// The terraform provider and the info-service package can then use the
+// mirror as their API endpoint. The local copy is kept inside of DIR/current
+// with the layout expected by images.DirectorySource, hence it can also be
+// copied into air-gapped networks.
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// imageStates are all the states reported by the API, including the
+// deleted images that are not accepted by images.ValidateState
+var imageStates = append(append([]string{}, images.ValidImageStates...), "deleted")
+
+// Status describes the outcome of the synchronizations of a Mirror
+type Status struct {
+	// LastSync is the time of the last successful synchronization
+	LastSync time.Time `json:"last_sync"`
+	// LastAttempt is the time of the last synchronization, successful or not
+	LastAttempt time.Time `json:"last_attempt"`
+	// LastError is the error of the last synchronization, empty when it
+	// succeeded
+	LastError string `json:"last_error,omitempty"`
+	// Providers are the cloud frameworks copied by the last successful
+	// synchronization
+	Providers []string `json:"providers"`
+	// DataVersions holds the data version of the images of each provider
+	DataVersions map[string]string `json:"data_versions"`
+}
+
+// Mirror copies the documents of the info service into a local directory
+// and serves them over HTTP
+type Mirror struct {
+	// Upstream is the source of the documents, usually the public instance
+	// of the info service
+	Upstream images.Source
+	// APIVersion is the version of the API to be mirrored, defaults to
+	// images.APIVersion
+	APIVersion string
+	// Providers are the cloud frameworks to be mirrored, all the ones
+	// known by the upstream service when empty
+	Providers []string
+	// Dir is the directory holding the local copy
+	Dir string
+	// MaxAge is the age after which the local copy is reported as stale by
+	// the freshness endpoint. Zero disables the check.
+	MaxAge time.Duration
+
+	// syncMu serializes the synchronizations
+	syncMu   sync.Mutex
+	catalogs map[string]*images.Catalog
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// New returns a mirror of the upstream source storing its local copy inside
+// of dir. The status of the previous synchronizations is loaded from dir,
+// if any.
+func New(upstream images.Source, dir string) *Mirror {
+	m := &Mirror{
+		Upstream:   upstream,
+		APIVersion: images.APIVersion,
+		Dir:        dir,
+		catalogs:   make(map[string]*images.Catalog),
+	}
+
+	if data, err := os.ReadFile(m.statusPath()); err == nil {
+		if err := json.Unmarshal(data, &m.status); err != nil {
+			log.Printf("[WARN] ignoring invalid mirror status %s: %v", m.statusPath(), err)
+		}
+	}
+
+	return m
+}
+
+// CurrentDir returns the directory holding the current local copy
+func (m *Mirror) CurrentDir() string {
+	return filepath.Join(m.Dir, "current")
+}
+
+func (m *Mirror) statusPath() string {
+	return filepath.Join(m.Dir, "status.json")
+}
+
+// Status returns the status of the synchronizations
+func (m *Mirror) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// Run synchronizes the mirror immediately and then at every interval, until
+// stop is closed. The errors are logged and reported by Status.
+func (m *Mirror) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Sync(); err != nil {
+			log.Printf("[ERROR] mirror synchronization failed: %v", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync copies the documents of the selected providers from the upstream
+// source. The new copy replaces the current one only when all the
+// documents have been fetched.
+func (m *Mirror) Sync() error {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	started := time.Now()
+	versions, err := m.sync()
+
+	m.mu.Lock()
+	status := m.status
+	status.LastAttempt = started
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	} else {
+		status.LastSync = started
+		status.DataVersions = versions
+		status.Providers = make([]string, 0, len(versions))
+		for provider := range versions {
+			status.Providers = append(status.Providers, provider)
+		}
+		sort.Strings(status.Providers)
+	}
+	m.status = status
+	m.mu.Unlock()
+
+	if e := m.saveStatus(status); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+func (m *Mirror) saveStatus(status Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(m.statusPath(), data)
+}
+
+// sync builds a new local copy and swaps it with the current one, the data
+// versions of the mirrored providers are returned
+func (m *Mirror) sync() (map[string]string, error) {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	providers := m.Providers
+	if len(providers) == 0 {
+		var err error
+		if providers, err = images.GetProvidersFrom(m.Upstream, m.APIVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	staging, err := os.MkdirTemp(m.Dir, ".staging-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	w := snapshotWriter{dir: staging, version: m.APIVersion}
+	if err := w.write(map[string]interface{}{"providers": names(providers)}, "providers.json"); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string)
+	for _, provider := range providers {
+		version, err := m.syncProvider(&w, provider)
+		if err != nil {
+			return nil, fmt.Errorf("error while mirroring %s: %v", provider, err)
+		}
+		versions[provider] = version
+	}
+
+	return versions, m.swap(staging)
+}
+
+// syncProvider writes all the documents of a provider, its data version is
+// returned
+func (m *Mirror) syncProvider(w *snapshotWriter, provider string) (string, error) {
+	catalog, ok := m.catalogs[provider]
+	if !ok {
+		catalog = images.NewCatalog("", m.APIVersion, provider)
+		catalog.Source = m.Upstream
+		m.catalogs[provider] = catalog
+	}
+	// the images are downloaded again only when the data version changed
+	if err := catalog.Refresh(); err != nil {
+		return "", err
+	}
+
+	all, err := catalog.Search(images.SearchParams{SortAscending: true})
+	if err != nil {
+		return "", err
+	}
+
+	regions, err := images.GetRegionsFrom(m.Upstream, m.APIVersion, provider)
+	if err != nil {
+		return "", err
+	}
+
+	servers := make([]images.Server, 0)
+	for _, serverType := range images.ValidServerTypes {
+		found, err := images.GetServers(images.ServerSearchParams{
+			Source:     m.Upstream,
+			APIVersion: m.APIVersion,
+			Cloud:      provider,
+			Type:       serverType,
+		})
+		if err != nil {
+			return "", err
+		}
+		servers = append(servers, found...)
+	}
+
+	if err := w.write(map[string]interface{}{"regions": names(regions)}, provider, "regions.json"); err != nil {
+		return "", err
+	}
+	if err := w.write(map[string]interface{}{"version": catalog.Version()}, provider, "dataversion"); err != nil {
+		return "", err
+	}
+
+	// the region-less documents, followed by the ones of each region
+	scopes := append([]string{""}, regions...)
+	for _, image := range all {
+		if !contains(scopes, image.Region) {
+			scopes = append(scopes, image.Region)
+		}
+	}
+
+	for _, region := range scopes {
+		if err := w.writeImages(provider, region, all); err != nil {
+			return "", err
+		}
+		if err := w.writeServers(provider, region, servers); err != nil {
+			return "", err
+		}
+	}
+
+	return catalog.Version(), nil
+}
+
+// swap replaces the current local copy with the staging one
+func (m *Mirror) swap(staging string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.CurrentDir()
+	old := ""
+	if _, err := os.Stat(current); err == nil {
+		old = staging + ".old"
+		if err := os.Rename(current, old); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(staging, current); err != nil {
+		if old != "" {
+			_ = os.Rename(old, current)
+		}
+		return err
+	}
+
+	if old != "" {
+		if err := os.RemoveAll(old); err != nil {
+			log.Printf("[WARN] failed to remove the previous copy %s: %v", old, err)
+		}
+	}
+
+	return nil
+}
+
+// snapshotWriter writes the documents of the API inside of a directory,
+// using the layout expected by images.DirectorySource
+type snapshotWriter struct {
+	dir     string
+	version string
+}
+
+func (w *snapshotWriter) write(v interface{}, elem ...string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	p := filepath.Join(append([]string{w.dir, w.version}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, data, 0644)
+}
+
+// writeImages writes the images documents of the region, or the
+// region-less ones when region is empty
+func (w *snapshotWriter) writeImages(provider, region string, all []images.Image) error {
+	byState := make(map[string][]images.Image)
+	inRegion := make([]images.Image, 0)
+	for _, image := range all {
+		if region != "" && image.Region != region {
+			continue
+		}
+		inRegion = append(inRegion, image)
+		byState[image.State] = append(byState[image.State], image)
+	}
+
+	prefix := scope(provider, region)
+	if err := w.write(map[string]interface{}{"images": inRegion}, append(prefix, "images.json")...); err != nil {
+		return err
+	}
+	for _, state := range imageStates {
+		found := byState[state]
+		if found == nil {
+			found = []images.Image{}
+		}
+		if err := w.write(map[string]interface{}{"images": found},
+			append(prefix, "images", state+".json")...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeServers writes the servers documents of the region, or the
+// region-less ones when region is empty
+func (w *snapshotWriter) writeServers(provider, region string, all []images.Server) error {
+	byType := make(map[string][]images.Server)
+	inRegion := make([]images.Server, 0)
+	for _, server := range all {
+		if region != "" && server.Region != region {
+			continue
+		}
+		inRegion = append(inRegion, server)
+		byType[server.Type] = append(byType[server.Type], server)
+	}
+
+	prefix := scope(provider, region)
+	if err := w.write(map[string]interface{}{"servers": inRegion}, append(prefix, "servers.json")...); err != nil {
+		return err
+	}
+	for _, serverType := range images.ValidServerTypes {
+		found := byType[serverType]
+		if found == nil {
+			found = []images.Server{}
+		}
+		if err := w.write(map[string]interface{}{"servers": found},
+			append(prefix, "servers", serverType+".json")...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scope(provider, region string) []string {
+	if region == "" {
+		return []string{provider}
+	}
+	return []string{provider, region}
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+func names(values []string) []named {
+	res := make([]named, 0, len(values))
+	for _, v := range values {
+		res = append(res, named{Name: v})
+	}
+	return res
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic replaces the file at path with data
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
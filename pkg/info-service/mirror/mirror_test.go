@@ -0,0 +1,172 @@
+package mirror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+)
+
+func newUpstream(t *testing.T) *fake.Server {
+	catalog, err := fake.LoadDir("../fake/testdata")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return fake.NewServer(catalog)
+}
+
+func getStatus(t *testing.T, url string) (int, map[string]interface{}) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("failed to close body: %v", err)
+		}
+	}()
+
+	reply := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return resp.StatusCode, reply
+}
+
+func TestMirrorServesUpstreamDocuments(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	m := New(images.NewHTTPSource(upstream.URL), t.TempDir())
+	m.Providers = []string{"amazon"}
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	if code, _ := getStatus(t, srv.URL+HealthPath); code != http.StatusServiceUnavailable {
+		t.Fatalf("Unexpected health status before the first sync. Got %d, expected %d",
+			code, http.StatusServiceUnavailable)
+	}
+
+	if err := m.Sync(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, state := range []string{"active", "deprecated"} {
+		params := images.SearchParams{Cloud: "amazon", Region: "eu-central-1", State: state}
+
+		params.APIEndpoint = upstream.URL
+		expected, err := images.GetImages(params)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		params.APIEndpoint = srv.URL
+		found, err := images.GetImages(params)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !reflect.DeepEqual(found, expected) {
+			t.Fatalf("Unexpected %s images. Got %+v, expected %+v", state, found, expected)
+		}
+	}
+
+	servers, err := images.GetServers(images.ServerSearchParams{
+		APIEndpoint: srv.URL,
+		Cloud:       "amazon",
+		Region:      "eu-central-1",
+		Type:        "regionserver",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("Unexpected number of servers. Got %d, expected %d", len(servers), 2)
+	}
+
+	catalog := images.NewCatalog(srv.URL, "", "amazon")
+	if err := catalog.Refresh(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if catalog.Version() != "1" {
+		t.Fatalf("Unexpected data version. Got %s, expected %s", catalog.Version(), "1")
+	}
+
+	providers, err := images.GetProviders(srv.URL, "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(providers, []string{"amazon"}) {
+		t.Fatalf("Unexpected providers %v", providers)
+	}
+
+	// the providers which are not mirrored are not served
+	if _, err := images.GetRegions(srv.URL, "", "microsoft"); err == nil {
+		t.Fatalf("The microsoft documents should not be mirrored")
+	}
+
+	if code, _ := getStatus(t, srv.URL+HealthPath); code != http.StatusOK {
+		t.Fatalf("Unexpected health status. Got %d, expected %d", code, http.StatusOK)
+	}
+	code, reply := getStatus(t, srv.URL+FreshnessPath)
+	if code != http.StatusOK || reply["stale"] != false {
+		t.Fatalf("Unexpected freshness %d %v", code, reply)
+	}
+	if versions := reply["data_versions"].(map[string]interface{}); versions["amazon"] != "1" {
+		t.Fatalf("Unexpected data versions %v", versions)
+	}
+
+	m.MaxAge = time.Nanosecond
+	if code, _ := getStatus(t, srv.URL+FreshnessPath); code != http.StatusServiceUnavailable {
+		t.Fatalf("Unexpected freshness status. Got %d, expected %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestMirrorKeepsCopyOnFailure(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	m := New(images.NewHTTPSource(upstream.URL), dir)
+	if err := m.Sync(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got := m.Status().Providers; !reflect.DeepEqual(got, []string{"amazon", "microsoft"}) {
+		t.Fatalf("Unexpected mirrored providers %v", got)
+	}
+
+	upstream.AddHook(fake.WithStatus("/v1/microsoft/regions.json", http.StatusInternalServerError))
+	if err := m.Sync(); err == nil {
+		t.Fatalf("The synchronization should have failed")
+	}
+
+	status := m.Status()
+	if status.LastError == "" || !status.LastAttempt.After(status.LastSync) {
+		t.Fatalf("Unexpected status after a failure %+v", status)
+	}
+
+	// the previous copy is still served
+	found, err := images.GetImages(images.SearchParams{
+		Source: images.NewDirectorySource(m.CurrentDir()),
+		Cloud:  "microsoft",
+		Region: "East US 2",
+		State:  "active",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Unexpected number of images. Got %d, expected %d", len(found), 1)
+	}
+
+	// the status survives restarts
+	if restarted := New(images.NewHTTPSource(upstream.URL), dir).Status(); !restarted.LastSync.Equal(status.LastSync) {
+		t.Fatalf("Unexpected status after a restart %+v", restarted)
+	}
+}
@@ -0,0 +1,112 @@
+package mirror
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// Paths of the operational endpoints of the mirror
+const (
+	HealthPath    = "/healthz"
+	FreshnessPath = "/freshness"
+)
+
+// freshness is the reply of the freshness endpoint
+type freshness struct {
+	Status
+	// Age is the number of seconds elapsed since the last successful
+	// synchronization, -1 when the mirror has never been synchronized
+	Age   int64 `json:"age_seconds"`
+	Stale bool  `json:"stale"`
+}
+
+// ServeHTTP serves the documents of the local copy using the paths of the
+// upstream API, together with the following endpoints:
+//
+//	/healthz    200 once a local copy is available, 503 otherwise
+//	/freshness  the Status of the mirror, 503 when the copy is stale
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	switch r.URL.Path {
+	case HealthPath:
+		m.serveHealth(w)
+	case FreshnessPath:
+		m.serveFreshness(w)
+	default:
+		m.serveDocument(w, r)
+	}
+}
+
+func (m *Mirror) serveHealth(w http.ResponseWriter) {
+	if m.Status().LastSync.IsZero() {
+		writeError(w, http.StatusServiceUnavailable, "the mirror has not been synchronized yet")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (m *Mirror) serveFreshness(w http.ResponseWriter) {
+	reply := freshness{Status: m.Status(), Age: -1}
+	if !reply.LastSync.IsZero() {
+		reply.Age = int64(time.Since(reply.LastSync).Seconds())
+	}
+	reply.Stale = reply.LastSync.IsZero() ||
+		(m.MaxAge > 0 && time.Since(reply.LastSync) > m.MaxAge)
+
+	code := http.StatusOK
+	if reply.Stale {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, reply)
+}
+
+func (m *Mirror) serveDocument(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/"+m.APIVersion+"/") {
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+		return
+	}
+
+	// the read lock prevents the local copy from being swapped while it is
+	// being read
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	body, err := images.NewDirectorySource(m.CurrentDir()).Open(r.URL.Path, r.URL.RawQuery)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+		return
+	}
+	defer func() {
+		if e := body.Close(); e != nil {
+			log.Printf("failed to close document: %v", e)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("failed to write reply: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write reply: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
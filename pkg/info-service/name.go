@@ -0,0 +1,142 @@
+package images
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NameInfo holds the information encoded inside of the name of an image
+// published by SUSE, for example:
+//
+//	suse-sles-sap-15-sp1-byos-v20190624-hvm-ssd-x86_64
+//
+// is parsed into:
+//
+//	Product:   "sles"
+//	Variant:   "sap"
+//	Version:   15
+//	SP:        1
+//	License:   "byos"
+//	BuildDate: 2019-06-24
+//	Virt:      "hvm"
+//	Arch:      "x86_64"
+//	Family:    "suse-sles-sap-15-sp1-byos-hvm-ssd-x86_64"
+type NameInfo struct {
+	Product   string
+	Variant   string
+	Version   int
+	SP        int
+	License   string
+	Virt      string
+	Arch      string
+	BuildDate time.Time
+	Family    string
+}
+
+// The license types found inside of image names
+const (
+	LicenseBYOS = "byos"
+	LicensePAYG = "payg"
+)
+
+// The architectures found inside of image names
+const (
+	ArchX86_64 = "x86_64"
+	ArchARM64  = "arm64"
+)
+
+// PublishedOnLayout is the layout of the dates returned by the
+// SUSE public cloud info service API, like "20190624"
+const PublishedOnLayout = "20060102"
+
+var (
+	buildDateToken = regexp.MustCompile(`^v(\d{8})$`)
+	spToken        = regexp.MustCompile(`^sp(\d+)$`)
+	numberToken    = regexp.MustCompile(`^\d+$`)
+)
+
+// ParseName extracts the product information encoded inside of the name
+// of an image. Parsing is best effort: the fields that cannot be found
+// inside of the name are left empty. The on-demand images do not state
+// their license inside of the name, hence LicensePAYG is assumed when
+// "byos" is not found. Likewise the names of some cloud frameworks, like
+// Microsoft Azure, state the architecture of the arm64 images only, hence
+// ArchX86_64 is assumed when no architecture is found.
+func ParseName(name string) NameInfo {
+	info := NameInfo{License: LicensePAYG, Arch: ArchX86_64}
+
+	tokens := strings.Split(strings.ToLower(name), "-")
+	if len(tokens) > 0 && tokens[0] == "suse" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 {
+		info.Product = tokens[0]
+		tokens = tokens[1:]
+	}
+	// multi-token product names, like sle-micro or opensuse-leap
+	if len(tokens) > 0 && (info.Product == "sle" && tokens[0] == "micro" ||
+		info.Product == "opensuse" && tokens[0] == "leap") {
+		info.Product += "-" + tokens[0]
+		tokens = tokens[1:]
+	}
+
+	variant := []string{}
+	versionFound := false
+	minorFound := false
+	family := []string{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// GCE uses "x86-64" instead of "x86_64"
+		if token == "x86" && i+1 < len(tokens) && tokens[i+1] == "64" {
+			info.Arch = ArchX86_64
+			family = append(family, token, tokens[i+1])
+			i++
+			continue
+		}
+
+		if m := buildDateToken.FindStringSubmatch(token); m != nil {
+			if t, err := time.Parse(PublishedOnLayout, m[1]); err == nil {
+				info.BuildDate = t
+				continue
+			}
+		}
+		family = append(family, token)
+
+		switch {
+		case numberToken.MatchString(token) && !versionFound:
+			info.Version, _ = strconv.Atoi(token)
+			versionFound = true
+		case numberToken.MatchString(token) && !minorFound:
+			// products like SUSE Manager and SLE Micro use "5-5" instead
+			// of "15-sp5"
+			info.SP, _ = strconv.Atoi(token)
+			minorFound = true
+		case spToken.MatchString(token):
+			info.SP, _ = strconv.Atoi(spToken.FindStringSubmatch(token)[1])
+			minorFound = true
+		case token == LicenseBYOS || token == LicensePAYG:
+			info.License = token
+		case token == "hvm" || token == "pv":
+			info.Virt = token
+		case token == ArchX86_64 || token == ArchARM64 || token == "aarch64":
+			info.Arch = token
+			if token == "aarch64" {
+				info.Arch = ArchARM64
+			}
+		case token == "ssd" || token == "gp2" || token == "gp3":
+			// storage type, not relevant
+		default:
+			variant = append(variant, token)
+		}
+	}
+	info.Variant = strings.Join(variant, "-")
+
+	prefix := strings.Split(strings.ToLower(name), "-")
+	prefix = prefix[:len(prefix)-len(tokens)]
+	info.Family = strings.Join(append(prefix, family...), "-")
+
+	return info
+}
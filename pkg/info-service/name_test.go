@@ -0,0 +1,103 @@
+package images
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected NameInfo
+	}{
+		{
+			name: "suse-sles-sap-15-sp1-byos-v20190624-hvm-ssd-x86_64",
+			expected: NameInfo{
+				Product:   "sles",
+				Variant:   "sap",
+				Version:   15,
+				SP:        1,
+				License:   LicenseBYOS,
+				Virt:      "hvm",
+				Arch:      "x86_64",
+				BuildDate: time.Date(2019, 6, 24, 0, 0, 0, 0, time.UTC),
+				Family:    "suse-sles-sap-15-sp1-byos-hvm-ssd-x86_64",
+			},
+		},
+		{
+			name: "suse-sles-12-sp3-sapcal-v20190623-hvm-ssd-x86_64",
+			expected: NameInfo{
+				Product:   "sles",
+				Variant:   "sapcal",
+				Version:   12,
+				SP:        3,
+				License:   LicensePAYG,
+				Virt:      "hvm",
+				Arch:      "x86_64",
+				BuildDate: time.Date(2019, 6, 23, 0, 0, 0, 0, time.UTC),
+				Family:    "suse-sles-12-sp3-sapcal-hvm-ssd-x86_64",
+			},
+		},
+		{
+			name: "suse-manager-4-0-proxy-byos-v20190725-hvm-ssd-x86_64",
+			expected: NameInfo{
+				Product:   "manager",
+				Variant:   "proxy",
+				Version:   4,
+				SP:        0,
+				License:   LicenseBYOS,
+				Virt:      "hvm",
+				Arch:      "x86_64",
+				BuildDate: time.Date(2019, 7, 25, 0, 0, 0, 0, time.UTC),
+				Family:    "suse-manager-4-0-proxy-byos-hvm-ssd-x86_64",
+			},
+		},
+		{
+			name: "sles-15-sp5-sap-byos-v20240101-x86-64",
+			expected: NameInfo{
+				Product:   "sles",
+				Variant:   "sap",
+				Version:   15,
+				SP:        5,
+				License:   LicenseBYOS,
+				Arch:      "x86_64",
+				BuildDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Family:    "sles-15-sp5-sap-byos-x86-64",
+			},
+		},
+		{
+			name: "suse-sle-micro-5-5-byos-v20231010-hvm-ssd-arm64",
+			expected: NameInfo{
+				Product:   "sle-micro",
+				Version:   5,
+				SP:        5,
+				License:   LicenseBYOS,
+				Virt:      "hvm",
+				Arch:      "arm64",
+				BuildDate: time.Date(2023, 10, 10, 0, 0, 0, 0, time.UTC),
+				Family:    "suse-sle-micro-5-5-byos-hvm-ssd-arm64",
+			},
+		},
+		{
+			// the Microsoft Azure names state the arm64 architecture only
+			name: "suse-sles-15-sp1-v20190624",
+			expected: NameInfo{
+				Product:   "sles",
+				Version:   15,
+				SP:        1,
+				License:   LicensePAYG,
+				Arch:      "x86_64",
+				BuildDate: time.Date(2019, 6, 24, 0, 0, 0, 0, time.UTC),
+				Family:    "suse-sles-15-sp1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		info := ParseName(test.name)
+		if info != test.expected {
+			t.Errorf("Unexpected parsing of %s. Got %+v, expected %+v",
+				test.name, info, test.expected)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Actions taken on the images violating the selection policy
+const (
+	PolicyModeFilter = "filter"
+	PolicyModeReject = "reject"
+)
+
+// Policy holds the guardrails applied to the images selected by the
+// searches, like the selection policy of the terraform provider. Empty rules
+// allow all the images.
+type Policy struct {
+	AllowedLicenses      []string
+	AllowedArchitectures []string
+	ForbidStates         []string
+	MaxImageAgeDays      int
+	AllowedNameRegex     *regexp.Regexp
+	// Mode is either PolicyModeFilter, the violating images are silently
+	// dropped, or PolicyModeReject, the query fails
+	Mode string
+}
+
+// ErrPolicyViolation is returned when the selection policy rejects the
+// images selected by a query
+var ErrPolicyViolation = errors.New("images violating the selection policy")
+
+// CriterionPolicy is the criterion of the explanations of the images
+// excluded by a Policy
+const CriterionPolicy = "policy"
+
+// PolicyViolation describes the first rule of the policy violated by an
+// image
+type PolicyViolation struct {
+	Image Image
+	// Rule is the name of the rule, like "allowed_licenses"
+	Rule    string
+	Message string
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("image %s (%s) violates the %s policy rule: %s",
+		v.Image.Name, v.Image.ID, v.Rule, v.Message)
+}
+
+// Check returns the violation of the policy by the image, nil when the
+// image is allowed. The age of the image is computed at now.
+func (p *Policy) Check(image Image, now time.Time) *PolicyViolation {
+	violation := func(rule, format string, a ...interface{}) *PolicyViolation {
+		return &PolicyViolation{Image: image, Rule: rule, Message: fmt.Sprintf(format, a...)}
+	}
+	info := ParseName(image.Name)
+
+	if len(p.AllowedLicenses) > 0 && !contains(p.AllowedLicenses, info.License) {
+		return violation("allowed_licenses", "license %q is not one of %s",
+			info.License, strings.Join(p.AllowedLicenses, ", "))
+	}
+	if len(p.AllowedArchitectures) > 0 && !contains(p.AllowedArchitectures, info.Arch) {
+		return violation("allowed_architectures", "architecture %q is not one of %s",
+			info.Arch, strings.Join(p.AllowedArchitectures, ", "))
+	}
+	if contains(p.ForbidStates, image.State) {
+		return violation("forbid_states", "state %q is forbidden", image.State)
+	}
+	if p.MaxImageAgeDays > 0 {
+		publishedOn, err := time.Parse(PublishedOnLayout, image.PublishedOn)
+		if err != nil {
+			return violation("max_image_age_days", "unknown publication date %q", image.PublishedOn)
+		}
+		if age := int(now.Sub(publishedOn).Hours() / 24); age > p.MaxImageAgeDays {
+			return violation("max_image_age_days", "published on %s, %d days ago, more than %d days",
+				publishedOn.Format(LifecycleDateLayout), age, p.MaxImageAgeDays)
+		}
+	}
+	if p.AllowedNameRegex != nil && !p.AllowedNameRegex.MatchString(image.Name) {
+		return violation("allowed_name_regex", "name does not match %q", p.AllowedNameRegex)
+	}
+
+	return nil
+}
+
+// Apply returns the images allowed by the policy at now. The violating
+// images are dropped, or an error wrapping ErrPolicyViolation and listing
+// them is returned when the policy rejects them. A nil policy allows all the
+// images.
+func (p *Policy) Apply(found []Image, now time.Time) ([]Image, error) {
+	if p == nil {
+		return found, nil
+	}
+
+	allowed := make([]Image, 0, len(found))
+	violations := make([]string, 0)
+	for _, image := range found {
+		if v := p.Check(image, now); v != nil {
+			violations = append(violations, v.String())
+			continue
+		}
+		allowed = append(allowed, image)
+	}
+
+	if len(violations) > 0 && p.Mode == PolicyModeReject {
+		return nil, fmt.Errorf("%w:\n  %s", ErrPolicyViolation, strings.Join(violations, "\n  "))
+	}
+	for _, v := range violations {
+		log.Printf("[INFO] Excluded by the selection policy: %s", v)
+	}
+
+	return allowed, nil
+}
+
+// Explain marks the selected images violating the policy at now as excluded
+// by it, regardless of the mode. The selected images are kept first.
+func (p *Policy) Explain(explanations []Explanation, now time.Time) []Explanation {
+	if p == nil {
+		return explanations
+	}
+
+	selected := make([]Explanation, 0, len(explanations))
+	excluded := make([]Explanation, 0)
+	for _, e := range explanations {
+		if e.Selected {
+			if v := p.Check(e.Image, now); v != nil {
+				e.Selected = false
+				e.Criterion = CriterionPolicy
+				e.Reason = fmt.Sprintf("%s rule: %s", v.Rule, v.Message)
+			}
+		}
+		if e.Selected {
+			selected = append(selected, e)
+		} else {
+			excluded = append(excluded, e)
+		}
+	}
+
+	return append(selected, excluded...)
+}
@@ -0,0 +1,90 @@
+package images
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	now := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	image := Image{
+		Name:        "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64",
+		State:       "active",
+		PublishedOn: "20190624",
+		ID:          "ami-0f9515259be7cd031",
+	}
+
+	tests := []struct {
+		policy   Policy
+		expected string
+	}{
+		{Policy{}, ""},
+		{Policy{AllowedLicenses: []string{"byos"}}, ""},
+		{Policy{AllowedLicenses: []string{"payg"}}, "allowed_licenses"},
+		{Policy{AllowedArchitectures: []string{"arm64", "x86_64"}}, ""},
+		{Policy{AllowedArchitectures: []string{"arm64"}}, "allowed_architectures"},
+		{Policy{ForbidStates: []string{"deprecated"}}, ""},
+		{Policy{ForbidStates: []string{"active"}}, "forbid_states"},
+		{Policy{MaxImageAgeDays: 7}, ""},
+		{Policy{MaxImageAgeDays: 6}, "max_image_age_days"},
+		{Policy{AllowedNameRegex: regexp.MustCompile("^suse-sles-15-")}, ""},
+		{Policy{AllowedNameRegex: regexp.MustCompile("^suse-sles-12-")}, "allowed_name_regex"},
+	}
+
+	for _, test := range tests {
+		v := test.policy.Check(image, now)
+		switch {
+		case test.expected == "" && v != nil:
+			t.Errorf("Unexpected violation of policy %+v: %s", test.policy, v)
+		case test.expected != "" && v == nil:
+			t.Errorf("Policy %+v should have been violated", test.policy)
+		case v != nil && v.Rule != test.expected:
+			t.Errorf("Unexpected rule violated. Got %s, expected %s", v.Rule, test.expected)
+		}
+	}
+	// the Microsoft Azure names state the arm64 architecture only
+	azure := Image{Name: "suse-sles-15-sp1-v20190624", State: "active", ID: "SUSE:sles-15-sp1:gen1:2019.06.24"}
+	policy := Policy{AllowedArchitectures: []string{"x86_64"}}
+	if v := policy.Check(azure, now); v != nil {
+		t.Errorf("Unexpected violation of policy %+v: %s", policy, v)
+	}
+	policy.AllowedArchitectures = []string{"arm64"}
+	if v := policy.Check(azure, now); v == nil || v.Rule != "allowed_architectures" {
+		t.Errorf("Unexpected violation of policy %+v: %v", policy, v)
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	found := []Image{
+		{Name: "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64", ID: "ami-1"},
+		{Name: "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64", ID: "ami-2"},
+	}
+
+	var policy *Policy
+	allowed, err := policy.Apply(found, time.Now())
+	if err != nil || len(allowed) != 2 {
+		t.Fatalf("A nil policy should allow all the images, got %v, %v", allowed, err)
+	}
+
+	policy = &Policy{AllowedLicenses: []string{"payg"}, Mode: PolicyModeFilter}
+	allowed, err = policy.Apply(found, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(allowed) != 1 || allowed[0].ID != "ami-2" {
+		t.Fatalf("Unexpected allowed images %v", allowed)
+	}
+
+	policy.Mode = PolicyModeReject
+	_, err = policy.Apply(found, time.Now())
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("The policy should have rejected the images, got %v", err)
+	}
+	expected := "image suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64 (ami-1) violates the allowed_licenses policy rule"
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Unexpected error. Got %q, expected it to contain %q", err, expected)
+	}
+}
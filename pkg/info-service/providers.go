@@ -0,0 +1,68 @@
+package images
+
+// Internally used to parse the response from
+// SUSE public cloud info service API
+type providersReply struct {
+	Providers []struct {
+		Name string `json:"name" xml:"name,attr"`
+	} `json:"providers" xml:"provider"`
+}
+
+// Internally used to parse the response from
+// SUSE public cloud info service API
+type regionsReply struct {
+	Regions []struct {
+		Name string `json:"name" xml:"name,attr"`
+	} `json:"regions" xml:"region"`
+}
+
+// GetProviders returns the names of the cloud frameworks known by the API,
+// as returned by
+// https://susepubliccloudinfo.suse.com/VERSION/providers.json
+func GetProviders(endpoint, version string) ([]string, error) {
+	return GetProvidersFrom(NewHTTPSource(endpoint), version)
+}
+
+// GetProvidersFrom is like GetProviders, reading the documents of the API
+// from the given source
+func GetProvidersFrom(src Source, version string) ([]string, error) {
+	providers := make([]string, 0)
+
+	var reply providersReply
+	if err := getDocument(src, apiPath(version, "providers.json"), "", &reply); err != nil {
+		return providers, err
+	}
+
+	for _, p := range reply.Providers {
+		providers = append(providers, p.Name)
+	}
+
+	return providers, nil
+}
+
+// GetRegions returns the names of the regions of a cloud framework, as
+// returned by
+// https://susepubliccloudinfo.suse.com/VERSION/FRAMEWORK/regions.json
+func GetRegions(endpoint, version, cloud string) ([]string, error) {
+	return GetRegionsFrom(NewHTTPSource(endpoint), version, cloud)
+}
+
+// GetRegionsFrom is like GetRegions, reading the documents of the API from
+// the given source
+func GetRegionsFrom(src Source, version, cloud string) ([]string, error) {
+	regions := make([]string, 0)
+	if err := validatePathElements(cloud, ""); err != nil {
+		return regions, err
+	}
+
+	var reply regionsReply
+	if err := getDocument(src, apiPath(version, cloud, "regions.json"), "", &reply); err != nil {
+		return regions, classifyNotFound(err, src, version, cloud, "")
+	}
+
+	for _, r := range reply.Regions {
+		regions = append(regions, r.Name)
+	}
+
+	return regions, nil
+}
@@ -0,0 +1,82 @@
+package images
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// regionKey folds the differences between the forms of a region name: case,
+// spaces, dashes, underscores and punctuation are ignored, hence "East US 2"
+// and "eastus2" share the same key
+func regionKey(region string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(region) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CanonicalRegion returns the name used by the API for the region of the
+// cloud framework, which can be given in any of its forms: the API one, an
+// alias of CloudInfo.RegionAliases or a differently spelled name, like
+// "eastus2" for "East US 2".
+//
+// known holds the regions returned by the API, when nil only the aliases are
+// looked up. The region is returned unchanged, together with false, when no
+// canonical name is found.
+func CanonicalRegion(cloud, region string, known []string) (string, bool) {
+	if region == "" || contains(known, region) {
+		return region, true
+	}
+
+	key := regionKey(region)
+	for alias, canonical := range Cloud(cloud).Info().RegionAliases {
+		if regionKey(alias) == key || regionKey(canonical) == key {
+			if known == nil || contains(known, canonical) {
+				return canonical, true
+			}
+			key = regionKey(canonical)
+			break
+		}
+	}
+
+	for _, candidate := range known {
+		if regionKey(candidate) == key {
+			return candidate, true
+		}
+	}
+
+	return region, false
+}
+
+// ResolveRegion returns the name used by the API for the region of the
+// cloud framework, see CanonicalRegion. The regions are fetched from the
+// source, the error matches ErrUnknownRegion when no region matches.
+func ResolveRegion(src Source, version, cloud, region string) (string, error) {
+	known, err := GetRegionsFrom(src, version, cloud)
+	if err != nil {
+		return region, err
+	}
+
+	canonical, ok := CanonicalRegion(cloud, region, known)
+	if !ok {
+		return region, fmt.Errorf("%w %q of %s, expected one of %s",
+			ErrUnknownRegion, region, cloud, strings.Join(known, ", "))
+	}
+	return canonical, nil
+}
+
+// validatePathElements rejects the cloud frameworks and the regions that
+// cannot be used as elements of the API paths
+func validatePathElements(cloud, region string) error {
+	if cloud == "." || cloud == ".." || strings.ContainsAny(cloud, "/\\") {
+		return fmt.Errorf("%w %q", ErrUnknownProvider, cloud)
+	}
+	if region == "." || region == ".." || strings.ContainsAny(region, "/\\") {
+		return fmt.Errorf("%w %q of %s", ErrUnknownRegion, region, cloud)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package images
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalRegion(t *testing.T) {
+	azure := []string{"East US", "East US 2", "West Europe"}
+	aws := []string{"eu-central-1", "us-east-1"}
+
+	for _, test := range []struct {
+		cloud    string
+		region   string
+		known    []string
+		expected string
+		found    bool
+	}{
+		{"microsoft", "East US 2", azure, "East US 2", true},
+		{"microsoft", "eastus2", azure, "East US 2", true},
+		{"microsoft", "westeurope", azure, "West Europe", true},
+		{"microsoft", "eastus3", azure, "eastus3", false},
+		{"amazon", "eu-central-1", aws, "eu-central-1", true},
+		{"amazon", "Europe (Frankfurt)", aws, "eu-central-1", true},
+		{"amazon", "EU-Central-1", aws, "eu-central-1", true},
+		{"amazon", "Europe (Ireland)", aws, "Europe (Ireland)", false},
+		{"amazon", "US East (N. Virginia)", nil, "us-east-1", true},
+		{"amazon", "mars-north-1", nil, "mars-north-1", false},
+		{"hpcloud", "region-a", []string{"region-a"}, "region-a", true},
+		{"amazon", "", aws, "", true},
+	} {
+		canonical, found := CanonicalRegion(test.cloud, test.region, test.known)
+		if canonical != test.expected || found != test.found {
+			t.Fatalf("Unexpected canonical region of %s %q. Got %q %v, expected %q %v",
+				test.cloud, test.region, canonical, found, test.expected, test.found)
+		}
+	}
+}
+
+func TestResolveRegion(t *testing.T) {
+	src := NewDirectorySource(writeSnapshotDir(t))
+
+	region, err := ResolveRegion(src, APIVersion, "amazon", "Europe (Frankfurt)")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if region != "eu-central-1" {
+		t.Fatalf("Unexpected region. Got %s, expected %s", region, "eu-central-1")
+	}
+
+	if _, err := ResolveRegion(src, APIVersion, "amazon", "mars-north-1"); !errors.Is(err, ErrUnknownRegion) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestRegionEscaping(t *testing.T) {
+	var requested string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.EscapedPath()
+		_, _ = w.Write([]byte(`{"images": []}`))
+	}))
+	defer srv.Close()
+
+	params := SearchParams{APIEndpoint: srv.URL, Cloud: "microsoft", Region: "East US 2#?", State: "active"}
+	if _, err := GetImages(params); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := "/v1/microsoft/East%20US%202%23%3F/images/active.json"
+	if requested != expected {
+		t.Fatalf("Unexpected path. Got %s, expected %s", requested, expected)
+	}
+
+	params.Region = "../amazon"
+	if _, err := GetImages(params); !errors.Is(err, ErrUnknownRegion) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
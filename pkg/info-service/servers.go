@@ -0,0 +1,93 @@
+package images
+
+import (
+	"fmt"
+)
+
+// Server describes an object returned by
+// https://susepubliccloudinfo.suse.com/VERSION/FRAMEWORK/REGION/servers/TYPE.json
+//
+//	{
+//	  "type": "regionserver",
+//	  "shape": "",
+//	  "name": "",
+//	  "ip": "52.28.243.25",
+//	  "region": "eu-central-1",
+//	  "ipv6": "2a05:d014:0cea:a201:0000:0000:0000:0005"
+//	},
+type Server struct {
+	Type   string `json:"type" xml:"type,attr"`
+	Shape  string `json:"shape,omitempty" xml:"shape,attr,omitempty"`
+	Name   string `json:"name,omitempty" xml:"name,attr,omitempty"`
+	IP     string `json:"ip" xml:"ip,attr"`
+	IPv6   string `json:"ipv6,omitempty" xml:"ipv6,attr,omitempty"`
+	Region string `json:"region" xml:"region,attr"`
+}
+
+// Internally used to parse the response from
+// SUSE public cloud info service API
+type serversReply struct {
+	Servers []Server `json:"servers" xml:"server"`
+}
+
+// ServerSearchParams is used to describe the search criteria to find one or
+// more servers of the SUSE update infrastructure
+type ServerSearchParams struct {
+	APIEndpoint string
+	APIVersion  string
+	Cloud       string
+	// Region is optional, all the regions are searched when empty
+	Region string
+	Type   string
+
+	// Source provides the documents of the API, when nil they are fetched
+	// from APIEndpoint
+	Source Source
+}
+
+// ValidServerTypes holds the valid types of servers as documented here:
+// https://github.com/SUSE-Enceladus/public-cloud-info-service#server-design
+var ValidServerTypes = []string{
+	"smt",
+	"regionserver",
+	"update",
+}
+
+// GetServers returns the list of servers of the SUSE update infrastructure
+// that match the search criteria provided by the user.
+func GetServers(params ServerSearchParams) ([]Server, error) {
+	servers := make([]Server, 0)
+
+	if err := ValidateServerType(params.Type); err != nil {
+		return servers, err
+	}
+	if err := validatePathElements(params.Cloud, params.Region); err != nil {
+		return servers, err
+	}
+
+	elem := []string{params.Cloud}
+	if params.Region != "" {
+		elem = append(elem, params.Region)
+	}
+	elem = append(elem, "servers", fmt.Sprintf("%s.json", params.Type))
+
+	var reply serversReply
+	src := sourceFor(params.Source, params.APIEndpoint)
+	if err := getDocument(src, apiPath(params.APIVersion, elem...), "", &reply); err != nil {
+		return servers, classifyNotFound(err, src, params.APIVersion, params.Cloud, params.Region)
+	}
+
+	return reply.Servers, nil
+}
+
+// ValidateServerType raises an error if the specified server type is not a
+// valid one
+func ValidateServerType(serverType string) error {
+	for _, vt := range ValidServerTypes {
+		if serverType == vt {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid server type: %s", serverType)
+}
@@ -0,0 +1,350 @@
+package images
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source provides the documents served by the info service API.
+//
+// The documents are identified by their path relative to the root of the
+// API, for example "v1/amazon/eu-central-1/images/active.json", and by an
+// optional query string, like "category=images".
+type Source interface {
+	// Open returns the document found at the given path
+	Open(path, query string) (io.ReadCloser, error)
+	// String describes the source inside of error messages
+	String() string
+}
+
+// DefaultHTTPTimeout is the time limit of the requests to the info service
+// sent by the HTTPSource without a Client
+const DefaultHTTPTimeout = 30 * time.Second
+
+// defaultHTTPClient is the client of the HTTPSource without a Client: an
+// endpoint that hangs must not block the failover to the next one
+var defaultHTTPClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+// HTTPSource is a Source fetching the documents from an instance of the
+// info service
+type HTTPSource struct {
+	// Endpoint is the URL of the info service, defaults to APIEndpoint
+	Endpoint string
+	// Client is the HTTP client used to fetch the documents, defaults to a
+	// client whose requests time out after DefaultHTTPTimeout. Use
+	// Auth.Client to present client certificates.
+	Client *http.Client
+	// Auth, when set, holds the credentials added to all the requests
+	Auth *Auth
+	// Format of the documents requested to the info service, defaults to
+	// FormatJSON. The documents are decoded into the same types regardless
+	// of their format.
+	Format Format
+}
+
+// NewHTTPSource returns a Source fetching the documents from the info
+// service found at the given endpoint
+func NewHTTPSource(endpoint string) *HTTPSource {
+	return &HTTPSource{Endpoint: endpoint}
+}
+
+func (s *HTTPSource) endpoint() string {
+	if s.Endpoint == "" {
+		return APIEndpoint
+	}
+	return s.Endpoint
+}
+
+// Open fetches the document with a GET request
+func (s *HTTPSource) Open(p, query string) (io.ReadCloser, error) {
+	u, err := s.documentURL(p, query)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.Auth != nil {
+		s.Auth.Apply(req)
+	}
+
+	// the credentials possibly embedded into the endpoint are never
+	// reported
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while accessing %s: %v", u.Redacted(), redactError(err, u))
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if e := resp.Body.Close(); e != nil {
+			log.Printf("failed to close response body: %v", e)
+		}
+		return nil, &HTTPError{StatusCode: resp.StatusCode, URL: u.Redacted(), Body: string(body)}
+	}
+
+	return resp.Body, nil
+}
+
+// documentURL returns the URL of the document. The path of the document is
+// appended to the one of the endpoint, hence instances of the info service
+// mounted under a sub-path, like https://mirror.example.com/susepubliccloud,
+// are supported. The path elements, like the regions, are escaped.
+func (s *HTTPSource) documentURL(p, query string) (*url.URL, error) {
+	base, err := url.Parse(s.endpoint())
+	if err != nil {
+		return nil, err
+	}
+
+	u := *base
+	u.Path = path.Join("/", base.Path, documentPath(p, s.Format))
+	u.RawPath = ""
+	u.RawQuery = query
+	u.Fragment = ""
+	return &u, nil
+}
+
+func (s *HTTPSource) String() string {
+	if u, err := url.Parse(s.endpoint()); err == nil {
+		return u.Redacted()
+	}
+	return s.endpoint()
+}
+
+// redactError removes the password embedded into u from the error returned
+// by the HTTP client
+func redactError(err error, u *url.URL) error {
+	if p, ok := u.User.Password(); ok && p != "" {
+		return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), p, "xxxxx"))
+	}
+	return err
+}
+
+// DirectorySource is a Source reading the documents from a directory
+// holding a snapshot of the API, using the same layout as the API paths:
+//
+//	DIR/v1/providers.json
+//	DIR/v1/amazon/regions.json
+//	DIR/v1/amazon/dataversion
+//	DIR/v1/amazon/images.json
+//	DIR/v1/amazon/eu-central-1/images/active.json
+//	...
+//
+// The query strings are ignored, "dataversion" holds the data version of
+// the images.
+type DirectorySource struct {
+	Dir string
+}
+
+// NewDirectorySource returns a Source reading the documents from the given
+// directory
+func NewDirectorySource(dir string) *DirectorySource {
+	return &DirectorySource{Dir: dir}
+}
+
+// Open opens the file of the document
+func (s *DirectorySource) Open(p, query string) (io.ReadCloser, error) {
+	name, err := sourcePath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(s.Dir, filepath.FromSlash(name))
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, fmt.Errorf("%s %w in %s", name, ErrNotFound, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while accessing %s in %s: %v", name, s, err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("error while accessing %s in %s: %v", name, s, err)
+	}
+
+	return f, nil
+}
+
+func (s *DirectorySource) String() string {
+	return fmt.Sprintf("directory %s", s.Dir)
+}
+
+// ArchiveSource is a Source reading the documents from an archive holding a
+// snapshot of the API, with the same layout used by DirectorySource. Tar
+// archives, optionally compressed with gzip, and zip archives are
+// supported. The whole archive is loaded in memory.
+type ArchiveSource struct {
+	path  string
+	files map[string][]byte
+}
+
+// NewArchiveSource loads the archive found at the given path. The format of
+// the archive is detected from its extension: ".zip", ".tar", ".tar.gz" or
+// ".tgz".
+func NewArchiveSource(archive string) (*ArchiveSource, error) {
+	s := &ArchiveSource{path: archive, files: make(map[string][]byte)}
+
+	var err error
+	switch name := strings.ToLower(archive); {
+	case strings.HasSuffix(name, ".zip"):
+		err = s.loadZip()
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		err = s.loadTar(true)
+	case strings.HasSuffix(name, ".tar"):
+		err = s.loadTar(false)
+	default:
+		return nil, fmt.Errorf("unsupported archive %s, expected a .zip, .tar, .tar.gz or .tgz file", archive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while loading archive %s: %v", archive, err)
+	}
+
+	return s, nil
+}
+
+func (s *ArchiveSource) loadZip() error {
+	r, err := zip.OpenReader(s.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := r.Close(); e != nil {
+			log.Printf("failed to close archive: %v", e)
+		}
+	}()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		if e := rc.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			return err
+		}
+		s.add(f.Name, data)
+	}
+
+	return nil
+}
+
+func (s *ArchiveSource) loadTar(compressed bool) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil {
+			log.Printf("failed to close archive: %v", e)
+		}
+	}()
+
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if e := gz.Close(); e != nil {
+				log.Printf("failed to close archive: %v", e)
+			}
+		}()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		s.add(hdr.Name, data)
+	}
+}
+
+func (s *ArchiveSource) add(name string, data []byte) {
+	if p, err := sourcePath(name); err == nil {
+		s.files[p] = data
+	}
+}
+
+// Open returns the content of the document
+func (s *ArchiveSource) Open(p, query string) (io.ReadCloser, error) {
+	name, err := sourcePath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s %w in %s", name, ErrNotFound, s)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Paths returns the sorted paths of all the files found inside of the
+// archive
+func (s *ArchiveSource) Paths() []string {
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+func (s *ArchiveSource) String() string {
+	return fmt.Sprintf("archive %s", s.path)
+}
+
+// sourcePath cleans the path of a document, paths escaping the root of the
+// snapshot are rejected
+func sourcePath(p string) (string, error) {
+	for _, elem := range strings.Split(p, "/") {
+		if elem == ".." {
+			return "", fmt.Errorf("invalid path %q", p)
+		}
+	}
+
+	return strings.TrimPrefix(path.Clean("/"+p), "/"), nil
+}
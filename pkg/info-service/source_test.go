@@ -0,0 +1,167 @@
+package images
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// snapshotFiles returns the documents of a snapshot of the API, indexed by
+// their path
+func snapshotFiles(t *testing.T) map[string][]byte {
+	active, err := os.ReadFile("testdata/active.json")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return map[string][]byte{
+		"v1/amazon/eu-central-1/images/active.json": active,
+		"v1/amazon/images.json":                     active,
+		"v1/amazon/dataversion":                     []byte(`{"version": "20190624"}`),
+		"v1/amazon/regions.json":                    []byte(`{"regions": [{"name": "eu-central-1"}]}`),
+		"v1/providers.json":                         []byte(`{"providers": [{"name": "amazon"}]}`),
+	}
+}
+
+func writeSnapshotDir(t *testing.T) string {
+	dir := t.TempDir()
+	for name, data := range snapshotFiles(t) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	return dir
+}
+
+func writeSnapshotTarGz(t *testing.T) string {
+	p := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, data := range snapshotFiles(t) {
+		hdr := &tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	for _, c := range []io.Closer{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	return p
+}
+
+func writeSnapshotZip(t *testing.T) string {
+	p := filepath.Join(t.TempDir(), "snapshot.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range snapshotFiles(t) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	for _, c := range []io.Closer{zw, f} {
+		if err := c.Close(); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	return p
+}
+
+func TestSources(t *testing.T) {
+	tarGz, err := NewArchiveSource(writeSnapshotTarGz(t))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	zipped, err := NewArchiveSource(writeSnapshotZip(t))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, src := range []Source{NewDirectorySource(writeSnapshotDir(t)), tarGz, zipped} {
+		t.Run(src.String(), func(t *testing.T) {
+			images, err := GetImages(SearchParams{
+				Source:        src,
+				Cloud:         "amazon",
+				Region:        "eu-central-1",
+				State:         "active",
+				SortAscending: true,
+				NameRegex:     "suse-sles-.*-sapcal.*-hvm-ssd-x86_64",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(images) != 3 {
+				t.Fatalf("Unexpected number of images. Got %d, expected %d", len(images), 3)
+			}
+
+			catalog := NewCatalog("", "", "amazon")
+			catalog.Source = src
+			if err := catalog.Refresh(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if catalog.Version() != "20190624" {
+				t.Fatalf("Unexpected data version. Got %s, expected %s", catalog.Version(), "20190624")
+			}
+
+			providers, err := GetProvidersFrom(src, "")
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(providers) != 1 || providers[0] != "amazon" {
+				t.Fatalf("Unexpected providers %v", providers)
+			}
+
+			_, err = GetImages(SearchParams{Source: src, Cloud: "amazon", Region: "us-east-1", State: "active"})
+			if err == nil || !strings.Contains(err.Error(), "v1/amazon/us-east-1/images/active.json not found") {
+				t.Fatalf("Unexpected error for a missing document: %v", err)
+			}
+
+			if _, err := src.Open("v1/../../etc/passwd", ""); err == nil {
+				t.Fatalf("Paths escaping the snapshot should be rejected")
+			}
+		})
+	}
+}
+
+func TestNewArchiveSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"snapshot.rar", "missing.tar.gz", "invalid.zip"} {
+		p := filepath.Join(dir, name)
+		if name != "missing.tar.gz" {
+			if err := os.WriteFile(p, []byte("garbage"), 0644); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+		if _, err := NewArchiveSource(p); err == nil {
+			t.Errorf("Loading %s should have failed", name)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The acceptance tests drive terraform configurations through the same
+// provider entry points used by terraform core: validation, configuration,
+// diff and apply. The resource.Test harness of the SDK cannot be used
+// because its embedded copy of terraform core does not build against the
+// afero release required by go-getter.
+
+// testAccState holds the flattened attributes of the data sources and of
+// the resources of a step, indexed by their address. The id is stored under
+// the "id" key.
+type testAccState map[string]map[string]string
+
+// testAccCheckFunc checks the state produced by a step
+type testAccCheckFunc func(s testAccState) error
+
+// testAccStep is a terraform configuration applied by an acceptance test
+type testAccStep struct {
+	// PreConfig is invoked before applying the configuration
+	PreConfig func()
+	Config    string
+	Check     testAccCheckFunc
+	// ExpectError, when set, must match the error raised by the step
+	ExpectError *regexp.Regexp
+}
+
+// testAccTest applies all the steps in order, each step is applied with a
+// fresh instance of the provider, like it happens with consecutive runs of
+// terraform. The state of the resources is kept between the steps.
+func testAccTest(t *testing.T, steps ...testAccStep) {
+	t.Helper()
+
+	resources := map[string]*terraform.InstanceState{}
+	for i, step := range steps {
+		if step.PreConfig != nil {
+			step.PreConfig()
+		}
+
+		state, err := testAccApply(step.Config, resources)
+		switch {
+		case step.ExpectError != nil && err == nil:
+			t.Fatalf("Step %d: expected an error matching %s", i+1, step.ExpectError)
+		case step.ExpectError != nil && !step.ExpectError.MatchString(err.Error()):
+			t.Fatalf("Step %d: expected an error matching %s, got %v", i+1, step.ExpectError, err)
+		case step.ExpectError == nil && err != nil:
+			t.Fatalf("Step %d: unexpected error %v", i+1, err)
+		}
+
+		if step.Check != nil && err == nil {
+			if err := step.Check(state); err != nil {
+				t.Fatalf("Step %d: %v", i+1, err)
+			}
+		}
+	}
+}
+
+// testAccApply parses the configuration, configures the provider, reads all
+// the data sources and applies the changes of all the resources. The state
+// of the resources is updated in place.
+func testAccApply(config string, resources map[string]*terraform.InstanceState) (testAccState, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(config), "test.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	provider := Provider().(*schema.Provider)
+	providerConfig := map[string]interface{}{}
+	blocks := file.Body.(*hclsyntax.Body).Blocks
+	for _, block := range blocks {
+		if block.Type == "provider" {
+			if providerConfig, diags = testAccBody(block.Body); diags.HasErrors() {
+				return nil, diags
+			}
+		}
+	}
+
+	cfg := terraform.NewResourceConfigRaw(providerConfig)
+	if _, es := provider.Validate(cfg); len(es) > 0 {
+		return nil, testAccErrors(es)
+	}
+	if err := provider.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	state := testAccState{}
+	for _, block := range blocks {
+		if block.Type != "data" && block.Type != "resource" {
+			continue
+		}
+
+		raw, diags := testAccBody(block.Body)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		var is *terraform.InstanceState
+		var err error
+		if block.Type == "data" {
+			is, err = testAccReadData(provider, block.Labels[0], block.Labels[1], raw)
+		} else {
+			is, err = testAccApplyResource(provider, block.Labels[0], block.Labels[1], raw, resources)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		attributes := map[string]string{}
+		for k, v := range is.Attributes {
+			attributes[k] = v
+		}
+		attributes["id"] = is.ID
+		state[testAccAddr(block.Type, block.Labels[0], block.Labels[1])] = attributes
+	}
+
+	return state, nil
+}
+
+func testAccAddr(blockType, resourceType, name string) string {
+	if blockType == "data" {
+		return fmt.Sprintf("data.%s.%s", resourceType, name)
+	}
+	return fmt.Sprintf("%s.%s", resourceType, name)
+}
+
+func testAccReadData(provider *schema.Provider, dataType, name string, raw map[string]interface{}) (*terraform.InstanceState, error) {
+	addr := testAccAddr("data", dataType, name)
+	info := &terraform.InstanceInfo{Id: addr, Type: dataType}
+	cfg := terraform.NewResourceConfigRaw(raw)
+
+	if _, es := provider.ValidateDataSource(dataType, cfg); len(es) > 0 {
+		return nil, fmt.Errorf("%s: %v", addr, testAccErrors(es))
+	}
+
+	diff, err := provider.ReadDataDiff(info, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", addr, err)
+	}
+
+	is, err := provider.ReadDataApply(info, diff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", addr, err)
+	}
+
+	return is, nil
+}
+
+func testAccApplyResource(provider *schema.Provider, resourceType, name string, raw map[string]interface{},
+	resources map[string]*terraform.InstanceState) (*terraform.InstanceState, error) {
+	addr := testAccAddr("resource", resourceType, name)
+	info := &terraform.InstanceInfo{Id: addr, Type: resourceType}
+	cfg := terraform.NewResourceConfigRaw(raw)
+
+	if _, es := provider.ValidateResource(resourceType, cfg); len(es) > 0 {
+		return nil, fmt.Errorf("%s: %v", addr, testAccErrors(es))
+	}
+
+	prior := resources[addr]
+	if prior != nil {
+		refreshed, err := provider.Refresh(info, prior)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", addr, err)
+		}
+		prior = refreshed
+	}
+
+	diff, err := provider.Diff(info, prior, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", addr, err)
+	}
+
+	is := prior
+	if diff != nil && !diff.Empty() {
+		if diff.RequiresNew() && prior != nil {
+			// replace the resource
+			prior = nil
+			if diff, err = provider.Diff(info, nil, cfg); err != nil {
+				return nil, fmt.Errorf("%s: %v", addr, err)
+			}
+		}
+		if is, err = provider.Apply(info, prior, diff); err != nil {
+			return nil, fmt.Errorf("%s: %v", addr, err)
+		}
+	}
+
+	resources[addr] = is
+	return is, nil
+}
+
+// testAccBody converts the attributes and the nested blocks of a body into
+// the raw values expected by terraform.NewResourceConfigRaw
+func testAccBody(body *hclsyntax.Body) (map[string]interface{}, hcl.Diagnostics) {
+	raw := map[string]interface{}{}
+
+	for name, attr := range body.Attributes {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		raw[name] = testAccValue(v)
+	}
+
+	for _, block := range body.Blocks {
+		nested, diags := testAccBody(block.Body)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		list, _ := raw[block.Type].([]interface{})
+		raw[block.Type] = append(list, nested)
+	}
+
+	return raw, nil
+}
+
+func testAccValue(v cty.Value) interface{} {
+	t := v.Type()
+
+	switch {
+	case v.IsNull():
+		return nil
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Bool:
+		return v.True()
+	case t == cty.Number:
+		bf := v.AsBigFloat()
+		if bf.IsInt() {
+			i, _ := bf.Int64()
+			return int(i)
+		}
+		f, _ := bf.Float64()
+		return f
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		list := []interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+			list = append(list, testAccValue(e))
+		}
+		return list
+	default:
+		m := map[string]interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			k, e := it.Element()
+			m[k.AsString()] = testAccValue(e)
+		}
+		return m
+	}
+}
+
+func testAccErrors(es []error) error {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
+
+// testAccComposeCheck runs all the checks in order
+func testAccComposeCheck(checks ...testAccCheckFunc) testAccCheckFunc {
+	return func(s testAccState) error {
+		for _, check := range checks {
+			if err := check(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// testAccCheckAttr checks the value of an attribute, using the flatmap
+// notation of terraform ("ids.#", "ids.0", ...)
+func testAccCheckAttr(addr, key, expected string) testAccCheckFunc {
+	return func(s testAccState) error {
+		attributes, ok := s[addr]
+		if !ok {
+			return fmt.Errorf("Not found: %s", addr)
+		}
+
+		v, ok := attributes[key]
+		if !ok && !(expected == "0" && strings.HasSuffix(key, "#")) {
+			return fmt.Errorf("%s: attribute %s not found", addr, key)
+		}
+		if v != expected && !(v == "" && expected == "0" && strings.HasSuffix(key, "#")) {
+			return fmt.Errorf("%s: attribute %s expected %q, got %q", addr, key, expected, v)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,405 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// Config holds the provider configuration, it is shared by all the data
+// sources
+type Config struct {
+	APIEndpoint            string
+	PreloadCatalog         bool
+	FailOnDeprecatedImages bool
+	// Source, when set, provides the documents of the API instead of the
+	// info service found at APIEndpoint
+	Source images.Source
+
+	// LockFile, when set, records the first resolution of each query of
+	// the susepubliccloud_image_ids data source
+	LockFile *images.LockFile
+	// Policy, when set, is applied to the images selected by all the data
+	// sources and resources
+	Policy *images.Policy
+	// RefreshLockFile resolves again the queries recorded inside of
+	// LockFile and updates their entries
+	RefreshLockFile bool
+
+	mu       sync.Mutex
+	catalogs map[string]*images.Catalog
+	// regions caches the regions of each cloud framework
+	regions map[string][]string
+}
+
+// catalog returns the catalog of the given cloud framework, creating it on
+// first use
+func (c *Config) catalog(params images.SearchParams) *images.Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.catalogs == nil {
+		c.catalogs = make(map[string]*images.Catalog)
+	}
+
+	catalog, ok := c.catalogs[params.Cloud]
+	if !ok {
+		catalog = images.NewCatalog(params.APIEndpoint, params.APIVersion, params.Cloud)
+		catalog.Source = c.Source
+		c.catalogs[params.Cloud] = catalog
+	}
+
+	return catalog
+}
+
+// canonicalRegion returns the name used by the API for the region of the
+// cloud framework, see images.CanonicalRegion. The regions returned by the
+// API are fetched once per cloud framework, only the region aliases are
+// looked up when they cannot be fetched: the query then reports the unknown
+// regions.
+func (c *Config) canonicalRegion(cloud, region string) string {
+	if region == "" {
+		return region
+	}
+
+	c.mu.Lock()
+	known, ok := c.regions[cloud]
+	c.mu.Unlock()
+
+	if !ok {
+		src := c.Source
+		if src == nil {
+			src = images.NewHTTPSource(c.APIEndpoint)
+		}
+		var err error
+		if known, err = images.GetRegionsFrom(src, "", cloud); err != nil {
+			log.Printf("[DEBUG] Cannot fetch the regions of %s, using the region aliases only: %v", cloud, err)
+			known = nil
+		} else {
+			c.mu.Lock()
+			if c.regions == nil {
+				c.regions = make(map[string][]string)
+			}
+			c.regions[cloud] = known
+			c.mu.Unlock()
+		}
+	}
+
+	canonical, _ := images.CanonicalRegion(cloud, region, known)
+	if canonical != region {
+		log.Printf("[DEBUG] Using the canonical name %q of region %q of %s", canonical, region, cloud)
+	}
+	return canonical
+}
+
+// searchImages is the query path shared by all the image data sources and
+// resources. The images are taken from the in-memory catalog of the cloud
+// framework when PreloadCatalog is set, otherwise the API is queried
+// directly. The selection policy is applied to the results. The errors of
+// the info service are mapped to precise diagnostics.
+//
+// The endpoint or the source that served the images is returned as well.
+func (c *Config) searchImages(params images.SearchParams) ([]images.Image, string, error) {
+	if params.APIEndpoint == "" {
+		params.APIEndpoint = c.APIEndpoint
+	}
+	if params.Source == nil {
+		params.Source = c.Source
+	}
+
+	var found []images.Image
+	var err error
+	if c.PreloadCatalog {
+		found, err = c.catalog(params).Search(params)
+	} else {
+		// the tracked copy reports the endpoint used by this query only
+		if failover, ok := params.Source.(*images.FailoverSource); ok {
+			params.Source = failover.Track()
+		}
+		found, err = images.GetImages(params)
+	}
+	if err != nil {
+		return nil, "", describeError(err, params)
+	}
+
+	found, err = c.Policy.Apply(found, timeNow())
+	if err != nil {
+		return nil, "", err
+	}
+	return found, servedBy(params), nil
+}
+
+// explainImages returns the explanation of every image considered by the
+// query, see images.ExplainImages. The images violating the selection
+// policy are reported as excluded by it.
+func (c *Config) explainImages(params images.SearchParams) ([]images.Explanation, error) {
+	if params.APIEndpoint == "" {
+		params.APIEndpoint = c.APIEndpoint
+	}
+	if params.Source == nil {
+		params.Source = c.Source
+	}
+
+	var explanations []images.Explanation
+	var err error
+	if c.PreloadCatalog {
+		explanations, err = c.catalog(params).Explain(params)
+	} else {
+		explanations, err = images.ExplainImages(params)
+	}
+	if err != nil {
+		return nil, describeError(err, params)
+	}
+
+	return c.Policy.Explain(explanations, timeNow()), nil
+}
+
+// regionImages returns all the images of the region, whatever their state,
+// using a single query. The selection policy is not applied. The endpoint or
+// the source that served the images is returned as well.
+func (c *Config) regionImages(params images.SearchParams) ([]images.Image, string, error) {
+	if params.APIEndpoint == "" {
+		params.APIEndpoint = c.APIEndpoint
+	}
+	if params.Source == nil {
+		params.Source = c.Source
+	}
+	params.State = ""
+	params.NameRegex = ""
+	params.Filter = ""
+
+	var found []images.Image
+	var err error
+	if c.PreloadCatalog {
+		found, err = c.catalog(params).Search(params)
+	} else {
+		if failover, ok := params.Source.(*images.FailoverSource); ok {
+			params.Source = failover.Track()
+		}
+		found, err = images.GetAllImages(params)
+	}
+	if err != nil {
+		return nil, "", describeError(err, params)
+	}
+	return found, servedBy(params), nil
+}
+
+// findImage returns the image of the region with the given id, or with the
+// given name when id is empty, regardless of its state. The selection
+// policy is not applied. The boolean is false when no image matches. The
+// preloaded catalog is looked up through its indexes, the listing of the
+// region is scanned otherwise.
+func (c *Config) findImage(params images.SearchParams, id, name string) (images.Image, bool, error) {
+	var candidates []images.Image
+	var err error
+	if c.PreloadCatalog {
+		candidates, err = c.catalogImages(params, id, name)
+	} else {
+		candidates, _, err = c.regionImages(params)
+	}
+	if err != nil {
+		return images.Image{}, false, err
+	}
+
+	for _, image := range candidates {
+		if id != "" && image.ID == id || id == "" && image.Name == name {
+			return image, true, nil
+		}
+	}
+	return images.Image{}, false, nil
+}
+
+// catalogImages returns the images of the region of the preloaded catalog
+// with the given id, or with the given name when id is empty
+func (c *Config) catalogImages(params images.SearchParams, id, name string) ([]images.Image, error) {
+	if params.APIEndpoint == "" {
+		params.APIEndpoint = c.APIEndpoint
+	}
+	if params.Source == nil {
+		params.Source = c.Source
+	}
+
+	var found []images.Image
+	var err error
+	if id != "" {
+		found, err = c.catalog(params).LookupID(id)
+	} else {
+		found, err = c.catalog(params).LookupName(name)
+	}
+	if err != nil {
+		return nil, describeError(err, params)
+	}
+
+	// the same id can be reported by more than one region
+	res := make([]images.Image, 0, len(found))
+	for _, image := range found {
+		if image.Region == params.Region {
+			res = append(res, image)
+		}
+	}
+	return res, nil
+}
+
+// lookupImage is like findImage, but fails when no image matches
+func (c *Config) lookupImage(params images.SearchParams, id, name string) (images.Image, error) {
+	image, ok, err := c.findImage(params, id, name)
+	switch {
+	case err != nil:
+		return images.Image{}, err
+	case ok:
+		return image, nil
+	case id != "":
+		return images.Image{}, fmt.Errorf("image_id: no image %q found in the %s region of %s", id, params.Region, params.Cloud)
+	}
+	return images.Image{}, fmt.Errorf("image_name: no image %q found in the %s region of %s", name, params.Region, params.Cloud)
+}
+
+// searchServers returns the servers of the SUSE update infrastructure
+// matching params. The errors of the info service are mapped to precise
+// diagnostics.
+func (c *Config) searchServers(params images.ServerSearchParams) ([]images.Server, error) {
+	if params.APIEndpoint == "" {
+		params.APIEndpoint = c.APIEndpoint
+	}
+	if params.Source == nil {
+		params.Source = c.Source
+	}
+
+	found, err := images.GetServers(params)
+	if err != nil {
+		return nil, describeError(err, images.SearchParams{Cloud: params.Cloud, Region: params.Region})
+	}
+	return found, nil
+}
+
+// servedBy describes the origin of the documents of the query. The shared
+// catalogs report the endpoint used by their last update.
+func servedBy(params images.SearchParams) string {
+	switch src := params.Source.(type) {
+	case nil:
+		return params.APIEndpoint
+	case *images.FailoverSource:
+		if served := src.Served(); served != nil {
+			return served.String()
+		}
+		return ""
+	default:
+		return src.String()
+	}
+}
+
+// lockedImageIDs returns the image IDs recorded for the query inside of the
+// lock file. Nothing is returned when there is no lock file, when the query
+// has not been recorded yet or when the lock file is being refreshed.
+func (c *Config) lockedImageIDs(params images.SearchParams) ([]string, bool) {
+	if c.LockFile == nil || c.RefreshLockFile {
+		return nil, false
+	}
+
+	return c.LockFile.Lookup(images.NewLockQuery(params))
+}
+
+// criterionLockFile is the criterion of the explanations of the images
+// selected by the query but not recorded inside of the lock file
+const criterionLockFile = "lock_file"
+
+// lockedImages resolves the image IDs recorded inside of the lock file into
+// the images of the region, in the recorded order, so that the selection
+// policy and the deprecation checks apply to the locked queries as well. The
+// recorded images that are not published anymore are kept, with their ID
+// only, and reported by the returned warnings.
+func (c *Config) lockedImages(params images.SearchParams, ids []string) ([]images.Image, []string, string, error) {
+	candidates, servedBy, err := c.regionImages(params)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	byID := make(map[string]images.Image, len(candidates))
+	for _, image := range candidates {
+		byID[image.ID] = image
+	}
+
+	resolved := make([]images.Image, 0, len(ids))
+	for _, id := range ids {
+		if image, ok := byID[id]; ok {
+			resolved = append(resolved, image)
+		}
+	}
+	allowed, err := c.Policy.Apply(resolved, timeNow())
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("lock_file: %w", err)
+	}
+	isAllowed := make(map[string]bool, len(allowed))
+	for _, image := range allowed {
+		isAllowed[image.ID] = true
+	}
+
+	found := make([]images.Image, 0, len(ids))
+	warnings := make([]string, 0)
+	for _, id := range ids {
+		image, ok := byID[id]
+		switch {
+		case !ok:
+			found = append(found, images.Image{ID: id})
+			warnings = append(warnings, fmt.Sprintf("locked image %s is not published anymore", id))
+		case isAllowed[id]:
+			found = append(found, image)
+		}
+	}
+	return found, warnings, servedBy, nil
+}
+
+// explainLocked updates the explanations of the query to report the images
+// returned for a locked query: ids are the selected ones, the images selected
+// by the query but not recorded inside of the lock file are excluded by it.
+// The selected images are kept first, in the order of ids.
+func explainLocked(explanations []images.Explanation, ids []string) []images.Explanation {
+	position := make(map[string]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+
+	selected := make([]images.Explanation, 0, len(ids))
+	excluded := make([]images.Explanation, 0, len(explanations))
+	for _, e := range explanations {
+		if _, ok := position[e.Image.ID]; ok {
+			e.Selected = true
+			e.Criterion = ""
+			e.Reason = ""
+			selected = append(selected, e)
+			continue
+		}
+		if e.Selected {
+			e.Selected = false
+			e.Criterion = criterionLockFile
+			e.Reason = "not recorded inside of the lock file"
+		}
+		excluded = append(excluded, e)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return position[selected[i].Image.ID] < position[selected[j].Image.ID]
+	})
+
+	return append(selected, excluded...)
+}
+
+// lockImageIDs records the image IDs resolved for the query inside of the
+// lock file, the file is saved when the entry changes
+func (c *Config) lockImageIDs(params images.SearchParams, ids []string) error {
+	if c.LockFile == nil {
+		return nil
+	}
+
+	change := c.LockFile.Set(images.NewLockQuery(params), ids)
+	if change == nil {
+		return nil
+	}
+	if change.Old != nil {
+		log.Printf("[INFO] Lock file %s: query %+v changed from %v to %v",
+			c.LockFile.Path, change.Query, change.Old, change.New)
+	}
+
+	return c.LockFile.Save()
+}
@@ -0,0 +1,159 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataSourceSUSEPublicCloudImageEquivalents() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceSUSEPublicCloudImageEquivalentsRead,
+		Schema: map[string]*schema.Schema{
+			"cloud": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateCloud,
+			},
+			"region": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"image_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"image_id", "image_name"},
+			},
+			"image_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"image_id", "image_name"},
+			},
+			"target": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cloud": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateCloud,
+						},
+						"region": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+					},
+				},
+			},
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "active",
+				ValidateFunc: validateState,
+			},
+			"reference_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"reference_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"build_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"equivalents": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cloud":      {Type: schema.TypeString, Computed: true},
+						"region":     {Type: schema.TypeString, Computed: true},
+						"id":         {Type: schema.TypeString, Computed: true},
+						"name":       {Type: schema.TypeString, Computed: true},
+						"exact":      {Type: schema.TypeBool, Computed: true},
+						"build_date": {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+// formatBuildDate formats the build date parsed from the name of the image,
+// like "2019-06-24". Empty when the name has no build date.
+func formatBuildDate(image images.Image) string {
+	date := images.ParseName(image.Name).BuildDate
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(images.LifecycleDateLayout)
+}
+
+func dataSourceSUSEPublicCloudImageEquivalentsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	params := images.SearchParams{
+		Cloud:  d.Get("cloud").(string),
+		Region: d.Get("region").(string),
+	}
+	params.Region = config.canonicalRegion(params.Cloud, params.Region)
+
+	log.Printf("[DEBUG] Reading the reference image: %+v", params)
+	reference, err := config.lookupImage(params, d.Get("image_id").(string), d.Get("image_name").(string))
+	if err != nil {
+		return err
+	}
+
+	equivalents := make([]interface{}, 0)
+	for _, t := range d.Get("target").([]interface{}) {
+		target := t.(map[string]interface{})
+		targetParams := images.SearchParams{
+			Cloud: target["cloud"].(string),
+			State: d.Get("state").(string),
+		}
+		targetParams.Region = config.canonicalRegion(targetParams.Cloud, target["region"].(string))
+
+		log.Printf("[DEBUG] Reading the candidate equivalent images: %+v", targetParams)
+		candidates, _, err := config.searchImages(targetParams)
+		if err != nil {
+			return fmt.Errorf("target %s %s: %w", targetParams.Cloud, targetParams.Region, err)
+		}
+
+		e, ok := images.FindEquivalent(reference, candidates)
+		if !ok {
+			log.Printf("[WARN] No image of the %s region of %s is equivalent to %s (%s)",
+				targetParams.Region, targetParams.Cloud, reference.Name, reference.ID)
+			continue
+		}
+		equivalents = append(equivalents, map[string]interface{}{
+			"cloud":      targetParams.Cloud,
+			"region":     targetParams.Region,
+			"id":         e.Image.ID,
+			"name":       e.Image.Name,
+			"exact":      e.Exact,
+			"build_date": formatBuildDate(e.Image),
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("equivalents/%s/%s/%s/%v",
+		params.Cloud, params.Region, reference.ID, d.Get("target")))))
+
+	if err := d.Set("reference_id", reference.ID); err != nil {
+		return err
+	}
+	if err := d.Set("reference_name", reference.Name); err != nil {
+		return err
+	}
+	if err := d.Set("build_date", formatBuildDate(reference)); err != nil {
+		return err
+	}
+	return d.Set("equivalents", equivalents)
+}
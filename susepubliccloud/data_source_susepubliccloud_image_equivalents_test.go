@@ -0,0 +1,169 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+)
+
+const testAccImageEquivalents = "data.susepubliccloud_image_equivalents.test"
+
+func TestAccDataSourceImageEquivalents_basic(t *testing.T) {
+	defer testAccServer.ResetHooks()
+
+	testAccTest(t,
+		testAccStep{
+			// the reference image is looked up in the listing of the region,
+			// the documents of the single states are not needed
+			PreConfig: func() {
+				testAccServer.AddHook(fake.WithStatus("/v1/amazon/eu-central-1/images/", http.StatusNotFound))
+			},
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_equivalents" "test" {
+  cloud    = "amazon"
+  region   = "Europe (Frankfurt)"
+  image_id = "ami-0352b14942c00b04b"
+
+  target {
+    cloud  = "microsoft"
+    region = "East US 2"
+  }
+  target {
+    cloud  = "alibaba"
+    region = "cn-beijing"
+  }
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageEquivalents, "reference_id", "ami-0352b14942c00b04b"),
+				testAccCheckAttr(testAccImageEquivalents, "reference_name", "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageEquivalents, "build_date", "2019-06-24"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.#", "2"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.cloud", "microsoft"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.id", "SUSE:sles-15-sp1:gen1:2019.06.24"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.exact", "true"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.1.cloud", "alibaba"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.1.id", "m-2ze0a1b2c3d4e5f6g7h8"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.1.exact", "true"),
+			),
+		},
+		testAccStep{
+			// the reference image is looked up by ID in the preloaded
+			// catalog, the documents of the region are not needed
+			PreConfig: func() {
+				testAccServer.ResetHooks()
+				testAccServer.AddHook(fake.WithStatus("/v1/amazon/eu-central-1/", http.StatusNotFound))
+			},
+			Config: fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint    = "%s"
+  preload_catalog = true
+}
+
+data "susepubliccloud_image_equivalents" "test" {
+  cloud    = "amazon"
+  region   = "eu-central-1"
+  image_id = "ami-0352b14942c00b04b"
+
+  target {
+    cloud  = "microsoft"
+    region = "East US 2"
+  }
+}
+`, testAccServer.URL),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageEquivalents, "reference_name", "suse-sles-15-sp1-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.#", "1"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.id", "SUSE:sles-15-sp1:gen1:2019.06.24"),
+			),
+		},
+		testAccStep{
+			PreConfig: testAccServer.ResetHooks,
+			// the deprecated build is only found when looking for deprecated
+			// images, the nearest active build is returned otherwise
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_equivalents" "test" {
+  cloud      = "microsoft"
+  region     = "East US 2"
+  image_name = "suse-sles-15-sp1-v20190301"
+
+  target {
+    cloud  = "amazon"
+    region = "eu-central-1"
+  }
+  target {
+    cloud  = "alibaba"
+    region = "cn-beijing"
+  }
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageEquivalents, "reference_id", "SUSE:sles-15-sp1:gen1:2019.03.01"),
+				testAccCheckAttr(testAccImageEquivalents, "build_date", "2019-03-01"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.#", "2"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.id", "ami-0352b14942c00b04b"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.exact", "false"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.build_date", "2019-06-24"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.1.id", "m-2ze0a1b2c3d4e5f6g7h8"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.1.exact", "false"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_equivalents" "test" {
+  cloud      = "microsoft"
+  region     = "East US 2"
+  image_name = "suse-sles-15-sp1-v20190301"
+  state      = "deprecated"
+
+  target {
+    cloud  = "alibaba"
+    region = "cn-beijing"
+  }
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.#", "1"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.id", "m-2ze9z8y7x6w5v4u3t2s1"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.0.exact", "true"),
+			),
+		},
+		testAccStep{
+			// there is no BYOS image on Azure
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_equivalents" "test" {
+  cloud    = "amazon"
+  region   = "eu-central-1"
+  image_id = "ami-0f9515259be7cd031"
+
+  target {
+    cloud  = "microsoft"
+    region = "East US 2"
+  }
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageEquivalents, "reference_name", "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageEquivalents, "equivalents.#", "0"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_equivalents" "test" {
+  cloud    = "amazon"
+  region   = "eu-central-1"
+  image_id = "ami-00000000000000000"
+
+  target {
+    cloud  = "microsoft"
+    region = "East US 2"
+  }
+}
+`),
+			ExpectError: regexp.MustCompile(`image_id: no image "ami-00000000000000000" found`),
+		},
+	)
+}
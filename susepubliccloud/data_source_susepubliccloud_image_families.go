@@ -0,0 +1,120 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataSourceSUSEPublicCloudImageFamilies() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceSUSEPublicCloudImageFamiliesRead,
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"filter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateFilter,
+			},
+			"cloud": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateCloud,
+			},
+			"region": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "active",
+				ValidateFunc: validateState,
+			},
+			"wait_for": waitForSchema(),
+			"families": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"family":                  {Type: schema.TypeString, Computed: true},
+						"id":                      {Type: schema.TypeString, Computed: true},
+						"name":                    {Type: schema.TypeString, Computed: true},
+						"count":                   {Type: schema.TypeInt, Computed: true},
+						"published_on":            {Type: schema.TypeString, Computed: true},
+						"general_support_ends_on": {Type: schema.TypeString, Computed: true},
+						"ltss_ends_on":            {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+			"latest_ids": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"served_by": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"canonical_region": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceSUSEPublicCloudImageFamiliesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	params := imageSearchParams(d)
+	params.Region = config.canonicalRegion(params.Cloud, params.Region)
+	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("families/%+v", params))))
+
+	if err := d.Set("canonical_region", params.Region); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Reading image families: %+v", params)
+	found, servedBy, err := config.waitForImages(params, expandWaitFor(d))
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Image families served by %s", servedBy)
+
+	families := make([]interface{}, 0)
+	latestIDs := make(map[string]string)
+	for _, f := range images.GroupFamilies(found) {
+		lifecycle, _ := images.ImageLifecycle(f.Latest)
+		families = append(families, map[string]interface{}{
+			"family":                  f.Name,
+			"id":                      f.Latest.ID,
+			"name":                    f.Latest.Name,
+			"count":                   f.Count,
+			"published_on":            f.Latest.PublishedOn,
+			"general_support_ends_on": lifecycle.GeneralSupportEnds,
+			"ltss_ends_on":            lifecycle.LTSSEnds,
+		})
+		latestIDs[f.Name] = f.Latest.ID
+	}
+
+	if err := d.Set("served_by", servedBy); err != nil {
+		return err
+	}
+	if err := d.Set("latest_ids", latestIDs); err != nil {
+		return err
+	}
+	return d.Set("families", families)
+}
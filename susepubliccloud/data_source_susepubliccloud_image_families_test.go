@@ -0,0 +1,75 @@
+package susepubliccloud
+
+import (
+	"regexp"
+	"testing"
+)
+
+const testAccImageFamilies = "data.susepubliccloud_image_families.test"
+
+func TestAccDataSourceImageFamilies_basic(t *testing.T) {
+	defer testAccResetCatalog(t)
+
+	config := testAccProviderConfig(`
+data "susepubliccloud_image_families" "test" {
+  cloud      = "amazon"
+  region     = "Europe (Frankfurt)"
+  name_regex = "suse-sles-15-sp1-byos.*"
+}
+`)
+
+	testAccTest(t,
+		testAccStep{
+			Config: config,
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageFamilies, "canonical_region", "eu-central-1"),
+				testAccCheckAttr(testAccImageFamilies, "families.#", "2"),
+				testAccCheckAttr(testAccImageFamilies, "families.0.family", "suse-sles-15-sp1-byos-hvm-ssd-arm64"),
+				testAccCheckAttr(testAccImageFamilies, "families.0.id", "ami-08d7e80118e53e581"),
+				testAccCheckAttr(testAccImageFamilies, "families.0.count", "1"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.family", "suse-sles-15-sp1-byos-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.published_on", "20190624"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.general_support_ends_on", "2021-01-31"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.ltss_ends_on", "2024-01-31"),
+				testAccCheckAttr(testAccImageFamilies, "latest_ids.%", "2"),
+				testAccCheckAttr(testAccImageFamilies, "latest_ids.suse-sles-15-sp1-byos-hvm-ssd-x86_64", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImageFamilies, "served_by", testAccServer.URL),
+			),
+		},
+		testAccStep{
+			PreConfig: func() { testAccPublishImage(t) },
+			Config:    config,
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageFamilies, "families.#", "2"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.name", "suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.count", "2"),
+				testAccCheckAttr(testAccImageFamilies, "families.1.published_on", "20191010"),
+				testAccCheckAttr(testAccImageFamilies, "latest_ids.suse-sles-15-sp1-byos-hvm-ssd-x86_64", "ami-0c0ffee0c0ffee000"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_families" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "no-such-image"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageFamilies, "families.#", "0"),
+				testAccCheckAttr(testAccImageFamilies, "latest_ids.%", "0"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_families" "test" {
+  cloud  = "amazon"
+  region = "mars-north-1"
+}
+`),
+			ExpectError: regexp.MustCompile(`region: unknown region "mars-north-1"`),
+		},
+	)
+}
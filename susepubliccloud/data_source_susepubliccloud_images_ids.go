@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"log"
+	"strings"
 
 	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
@@ -20,11 +21,17 @@ func dataSourceSUSEPublicCloudImageIDs() *schema.Resource {
 				ForceNew:     true,
 				ValidateFunc: validation.StringIsValidRegExp,
 			},
+			"filter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateFilter,
+			},
 			"cloud": {
 				Type:         schema.TypeString,
 				Required:     true,
 				ForceNew:     true,
-				ValidateFunc: validation.NoZeroValues,
+				ValidateFunc: validateCloud,
 			},
 			"region": {
 				Type:         schema.TypeString,
@@ -44,15 +51,89 @@ func dataSourceSUSEPublicCloudImageIDs() *schema.Resource {
 				Default:  false,
 				Optional: true,
 			},
+			"wait_for": waitForSchema(),
+			"explain": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"explanation": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":        {Type: schema.TypeString, Computed: true},
+						"name":      {Type: schema.TypeString, Computed: true},
+						"state":     {Type: schema.TypeString, Computed: true},
+						"selected":  {Type: schema.TypeBool, Computed: true},
+						"criterion": {Type: schema.TypeString, Computed: true},
+						"reason":    {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
 			"ids": {
 				Type:     schema.TypeList,
 				Computed: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"images": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":               {Type: schema.TypeString, Computed: true},
+						"name":             {Type: schema.TypeString, Computed: true},
+						"state":            {Type: schema.TypeString, Computed: true},
+						"region":           {Type: schema.TypeString, Computed: true},
+						"published_on":     {Type: schema.TypeString, Computed: true},
+						"deprecated_on":    {Type: schema.TypeString, Computed: true},
+						"deleted_on":       {Type: schema.TypeString, Computed: true},
+						"replacement_id":   {Type: schema.TypeString, Computed: true},
+						"replacement_name": {Type: schema.TypeString, Computed: true},
+						"extra": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"raw_json":                {Type: schema.TypeString, Computed: true},
+						"general_support_ends_on": {Type: schema.TypeString, Computed: true},
+						"ltss_ends_on":            {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+			"warnings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"served_by": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"canonical_region": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
+// validateCloud is a SchemaValidateFunc which tests if the provided value is
+// a cloud framework known by the info-service package
+func validateCloud(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := images.ParseCloud(v); err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+	}
+
+	return
+}
+
 // validaetState is a SchemaValidateFunc which tests if the provided value is
 // an accepted Image state
 func validateState(i interface{}, k string) (s []string, es []error) {
@@ -68,10 +149,41 @@ func validateState(i interface{}, k string) (s []string, es []error) {
 		return
 	}
 
+	if v == "deprecated" {
+		s = append(s, fmt.Sprintf("%s: deprecated images are scheduled for deletion, "+
+			"consider using their replacements", k))
+	}
+
 	return
 }
 
-func dataSourceSUSEPublicCloudImageIDsRead(d *schema.ResourceData, meta interface{}) error {
+// validateFilter is a SchemaValidateFunc which tests if the provided value is
+// a valid filter expression
+func validateFilter(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := images.CompileFilter(v); err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+		return
+	}
+
+	return
+}
+
+// schemaGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff
+type schemaGetter interface {
+	Get(key string) interface{}
+	GetOk(key string) (interface{}, bool)
+}
+
+// imageSearchParams builds the image search criteria from the arguments
+// shared by the image data sources and resources
+func imageSearchParams(d schemaGetter) images.SearchParams {
 	params := images.SearchParams{
 		Cloud:  d.Get("cloud").(string),
 		Region: d.Get("region").(string),
@@ -81,7 +193,7 @@ func dataSourceSUSEPublicCloudImageIDsRead(d *schema.ResourceData, meta interfac
 		params.State = v.(string)
 	}
 
-	if d.Get("sort_ascending").(bool) {
+	if v, ok := d.GetOk("sort_ascending"); ok && v.(bool) {
 		params.SortAscending = true
 	} else {
 		params.SortAscending = false
@@ -91,26 +203,175 @@ func dataSourceSUSEPublicCloudImageIDsRead(d *schema.ResourceData, meta interfac
 		params.NameRegex = nameRegex.(string)
 	}
 
-	log.Printf("[DEBUG] Reading image IDs: %+v", params)
-	images, err := images.GetImages(params)
+	if filter, ok := d.GetOk("filter"); ok {
+		params.Filter = filter.(string)
+	}
+
+	return params
+}
+
+func dataSourceSUSEPublicCloudImageIDsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	params := imageSearchParams(d)
+	params.Region = config.canonicalRegion(params.Cloud, params.Region)
+	d.SetId(imageIDsID(params))
+
+	if err := d.Set("canonical_region", params.Region); err != nil {
+		return err
+	}
+
+	lockedIDs, locked := config.lockedImageIDs(params)
+	var found []images.Image
+	var lockWarnings []string
+	var servedBy string
+	var err error
+	if locked {
+		log.Printf("[DEBUG] Using locked image IDs: %+v", params)
+		found, lockWarnings, servedBy, err = config.lockedImages(params, lockedIDs)
+	} else {
+		log.Printf("[DEBUG] Reading image IDs: %+v", params)
+		found, servedBy, err = config.waitForImages(params, expandWaitFor(d))
+	}
+	if err != nil {
+		if d.Get("explain").(bool) {
+			return explainFailure(config, params, lockedIDs, locked, err)
+		}
+		return err
+	}
+	log.Printf("[DEBUG] Image IDs served by %s", servedBy)
+
+	warnings, err := config.checkDeprecations(found)
 	if err != nil {
 		return err
 	}
+	for _, w := range lockWarnings {
+		log.Printf("[WARN] %s", w)
+	}
+	warnings = append(warnings, lockWarnings...)
 
 	imageIDs := make([]string, 0)
-	for _, image := range images {
+	for _, image := range found {
 		imageIDs = append(imageIDs, image.ID)
 	}
 
-	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("%+v", params))))
+	if !locked {
+		if err := config.lockImageIDs(params, imageIDs); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Set("warnings", warnings); err != nil {
+		return err
+	}
+	if err := d.Set("served_by", servedBy); err != nil {
+		return err
+	}
+	flattened, err := flattenImages(found)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("images", flattened); err != nil {
+		return err
+	}
+
+	explanation := make([]interface{}, 0)
+	if d.Get("explain").(bool) {
+		explanations, err := config.explainImages(params)
+		if err != nil {
+			return err
+		}
+		if locked {
+			explanations = explainLocked(explanations, imageIDs)
+		}
+		for _, e := range explanations {
+			log.Printf("[DEBUG] Image %s", e)
+			explanation = append(explanation, map[string]interface{}{
+				"id":        e.Image.ID,
+				"name":      e.Image.Name,
+				"state":     e.Image.State,
+				"selected":  e.Selected,
+				"criterion": e.Criterion,
+				"reason":    e.Reason,
+			})
+		}
+	}
+	if err := d.Set("explanation", explanation); err != nil {
+		return err
+	}
 	return d.Set("ids", imageIDs)
 }
 
+// explainFailure appends the explanation of the images of the region to
+// the error of a failed query, like the one of a selection policy rejecting
+// the images: the attributes of a data source are not stored when its read
+// fails, hence explanation would never be available. The error is returned
+// as it is when the images cannot be explained either.
+func explainFailure(config *Config, params images.SearchParams, lockedIDs []string, locked bool, err error) error {
+	explanations, explainErr := config.explainImages(params)
+	if explainErr != nil {
+		log.Printf("[WARN] Cannot explain the images: %v", explainErr)
+		return err
+	}
+	if locked {
+		explanations = explainLocked(explanations, lockedIDs)
+	}
+
+	lines := make([]string, 0, len(explanations))
+	for _, e := range explanations {
+		lines = append(lines, e.String())
+	}
+	return fmt.Errorf("%w\nexplanation:\n  %s", err, strings.Join(lines, "\n  "))
+}
+
+// flattenImages converts the images into the values of the images
+// attribute. The fields unknown to the provider are exposed by extra and
+// raw_json, hence the fields added to the info service can be used before
+// the provider knows about them.
+func flattenImages(found []images.Image) ([]interface{}, error) {
+	res := make([]interface{}, 0, len(found))
+	for _, image := range found {
+		raw, err := image.RawJSON()
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode the image %s: %v", image.ID, err)
+		}
+		lifecycle, _ := images.ImageLifecycle(image)
+		res = append(res, map[string]interface{}{
+			"id":                      image.ID,
+			"name":                    image.Name,
+			"state":                   image.State,
+			"region":                  image.Region,
+			"published_on":            image.PublishedOn,
+			"deprecated_on":           image.DeprecatedOn,
+			"deleted_on":              image.DeletedOn,
+			"replacement_id":          image.ReplacementID,
+			"replacement_name":        image.ReplacementName,
+			"extra":                   image.ExtraStrings(),
+			"raw_json":                raw,
+			"general_support_ends_on": lifecycle.GeneralSupportEnds,
+			"ltss_ends_on":            lifecycle.LTSSEnds,
+		})
+	}
+	return res, nil
+}
+
 // String hashes a string to a unique hashcode.
 //
 // Copied from hashicorp/terraform-plugin-sdk/helper/hashcode/hashcode.go
 // Because this is going to be dropped in future releases of the library
 //
+// imageIDsID returns the ID of the data source, computed from its arguments
+// only. The arguments of the first releases of the provider are formatted as
+// the search criteria used to be, keeping the IDs of the existing
+// configurations; the filter is appended when set.
+func imageIDsID(params images.SearchParams) string {
+	key := fmt.Sprintf("{APIEndpoint: APIVersion: Cloud:%s NameRegex:%s Region:%s SortAscending:%t State:%s}",
+		params.Cloud, params.NameRegex, params.Region, params.SortAscending, params.State)
+	if params.Filter != "" {
+		key += "/filter:" + params.Filter
+	}
+	return fmt.Sprintf("%d", stringTohashcode(key))
+}
+
 // crc32 returns a uint32, but for our use we need
 // and non negative integer. Here we cast to an integer
 // and invert it if the result is negative.
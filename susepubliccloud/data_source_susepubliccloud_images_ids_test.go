@@ -0,0 +1,1272 @@
+package susepubliccloud
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/bundle"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+)
+
+const testAccImageIDs = "data.susepubliccloud_image_ids.test"
+
+func TestAccDataSourceImageIDs_basic(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImageIDs, "state", "active"),
+				testAccCheckAttr(testAccImageIDs, "sort_ascending", "false"),
+				testAccCheckAttr(testAccImageIDs, "ids.#", "22"),
+			),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_alibaba(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "alibaba"
+  region = "cn-beijing"
+}
+`),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"m-2ze0a1b2c3d4e5f6g7h8"}),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_extraFields(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "microsoft"
+  region = "eastus2"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"SUSE:sles-15-sp1:gen1:2019.06.24"}),
+				testAccCheckAttr(testAccImageIDs, "images.#", "1"),
+				testAccCheckAttr(testAccImageIDs, "images.0.name", "suse-sles-15-sp1-v20190624"),
+				testAccCheckAttr(testAccImageIDs, "images.0.published_on", "20190624"),
+				testAccCheckAttr(testAccImageIDs, "images.0.extra.%", "2"),
+				testAccCheckAttr(testAccImageIDs, "images.0.extra.urn", "SUSE:sles-15-sp1:gen1:2019.06.24"),
+				testAccCheckAttr(testAccImageIDs, "images.0.extra.environment", "PublicAzure"),
+				testAccCheckAttr(testAccImageIDs, "images.0.general_support_ends_on", "2021-01-31"),
+				testAccCheckAttr(testAccImageIDs, "images.0.ltss_ends_on", "2024-01-31"),
+				// the document of the info service, with its empty fields
+				testAccCheckAttr(testAccImageIDs, "images.0.raw_json",
+					`{"name":"suse-sles-15-sp1-v20190624","state":"active","replacementname":"","replacementid":"",`+
+						`"publishedon":"20190624","deprecatedon":"","region":"East US 2",`+
+						`"id":"SUSE:sles-15-sp1:gen1:2019.06.24","deletedon":"",`+
+						`"urn":"SUSE:sles-15-sp1:gen1:2019.06.24","environment":"PublicAzure"}`),
+			),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_regionAliases(t *testing.T) {
+	config := func(region string) string {
+		return testAccProviderConfig(fmt.Sprintf(`
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "%s"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, region))
+	}
+
+	for _, region := range []string{"eu-central-1", "Europe (Frankfurt)", "EU Central 1"} {
+		t.Run(region, func(t *testing.T) {
+			testAccTest(t,
+				testAccStep{
+					Config: config(region),
+					Check: testAccComposeCheck(
+						testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+						testAccCheckAttr(testAccImageIDs, "region", region),
+						testAccCheckAttr(testAccImageIDs, "canonical_region", "eu-central-1"),
+					),
+				},
+			)
+		})
+	}
+}
+
+func TestAccDataSourceImageIDs_arguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name: "name_regex",
+			config: `
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+`,
+			expected: []string{"ami-0f9515259be7cd031"},
+		},
+		{
+			name: "sort_ascending",
+			config: `
+  name_regex     = "suse-sles-.*-sapcal.*-hvm-ssd-x86_64"
+  sort_ascending = true
+`,
+			expected: []string{
+				"ami-082bfb28e7de47e17",
+				"ami-057b6b1654d10ff7b",
+				"ami-07dd6bca2aa25c67d",
+			},
+		},
+		{
+			name: "sort_descending",
+			config: `
+  name_regex = "suse-sles-.*-sapcal.*-hvm-ssd-x86_64"
+`,
+			expected: []string{
+				"ami-057b6b1654d10ff7b",
+				"ami-07dd6bca2aa25c67d",
+				"ami-082bfb28e7de47e17",
+			},
+		},
+		{
+			name: "filter",
+			config: `
+  filter = "product == \"manager\" && version == 4 && variant == \"server\""
+`,
+			expected: []string{"ami-097beae131ccb74cd"},
+		},
+		{
+			name: "name_regex_and_filter",
+			config: `
+  name_regex = "^suse-sles-15-sp1"
+  filter     = "license == \"byos\" && arch == \"arm64\""
+`,
+			expected: []string{"ami-08d7e80118e53e581"},
+		},
+		{
+			name: "state_deprecated",
+			config: `
+  state = "deprecated"
+`,
+			expected: []string{"ami-01c2d3e4f5a6b7c8d"},
+		},
+		{
+			name: "state_inactive",
+			config: `
+  state = "inactive"
+`,
+			expected: []string{"ami-0a1b2c3d4e5f60718"},
+		},
+		{
+			name: "no_match",
+			config: `
+  name_regex = "^opensuse"
+`,
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testAccTest(t,
+				testAccStep{
+					Config: testAccProviderConfig(fmt.Sprintf(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+%s
+}
+`, test.config)),
+					Check: testAccCheckImageIDs(testAccImageIDs, test.expected),
+				},
+			)
+		})
+	}
+}
+
+func TestAccDataSourceImageIDs_preloadCatalog(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint    = "%s"
+  preload_catalog = true
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "us-east-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, testAccServer.URL),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-0b1a7c3e4d5f6a7b8"}),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name: "invalid_state",
+			config: `
+  region = "eu-central-1"
+  state  = "deleted"
+`,
+			expected: "invalid image state: deleted",
+		},
+		{
+			name: "invalid_name_regex",
+			config: `
+  region     = "eu-central-1"
+  name_regex = "suse-("
+`,
+			expected: `"name_regex": error parsing regexp`,
+		},
+		{
+			name: "invalid_filter",
+			config: `
+  region = "eu-central-1"
+  filter = "sp >= \"4\""
+`,
+			expected: `filter: invalid filter expression at position 3: cannot compare int with string`,
+		},
+		{
+			name: "empty_region",
+			config: `
+  region = ""
+`,
+			expected: `region`,
+		},
+		{
+			name: "unknown_region",
+			config: `
+  region = "mars-north-1"
+`,
+			expected: `region: unknown region "mars-north-1" of amazon: unexpected HTTP status 404`,
+		},
+		{
+			name: "region_hint",
+			config: `
+  region = "Central Europe"
+`,
+			expected: `(the regions of Amazon EC2 are named like "us-east-1")`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testAccTest(t,
+				testAccStep{
+					Config: testAccProviderConfig(fmt.Sprintf(`
+data "susepubliccloud_image_ids" "test" {
+  cloud = "amazon"
+%s
+}
+`, test.config)),
+					ExpectError: regexp.MustCompile(regexp.QuoteMeta(test.expected)),
+				},
+			)
+		})
+	}
+}
+
+func TestAccDataSourceImageIDs_deprecationWarnings(t *testing.T) {
+	config := `
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+  state  = "deprecated"
+}
+`
+
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(config),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-01c2d3e4f5a6b7c8d"}),
+				testAccCheckAttr(testAccImageIDs, "warnings.#", "1"),
+				testAccCheckAttr(testAccImageIDs, "warnings.0",
+					"image suse-sles-15-sp1-byos-v20190301-hvm-ssd-x86_64 (ami-01c2d3e4f5a6b7c8d) "+
+						"is deprecated since 2019-06-24, it is replaced by "+
+						"suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64 (ami-0f9515259be7cd031)"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+}
+`),
+			Check: testAccCheckAttr(testAccImageIDs, "warnings.#", "0"),
+		},
+		testAccStep{
+			Config: fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint              = "%s"
+  fail_on_deprecated_images = true
+}
+%s`, testAccServer.URL, config),
+			ExpectError: regexp.MustCompile(`deprecated images selected:\s+image suse-sles-15-sp1-byos-v20190301`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_policy(t *testing.T) {
+	config := func(policy string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "%s"
+%s
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-.*-hvm-ssd"
+}
+`, testAccServer.URL, policy)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(`
+  allowed_licenses      = ["byos"]
+  allowed_architectures = ["arm64"]
+`),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-08d7e80118e53e581"}),
+		},
+		testAccStep{
+			Config: config(`
+  allowed_name_regex = "^suse-sles-15-sp1-byos"
+  policy_mode        = "reject"
+`),
+			ExpectError: regexp.MustCompile(`images violating the selection policy:\s+image suse-sles-15-sp1-v\d+-hvm-ssd-\w+ \(ami-\w+\) violates the allowed_name_regex policy rule`),
+		},
+		testAccStep{
+			Config: config(`
+  max_image_age_days = 30
+`),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{}),
+		},
+		testAccStep{
+			Config: config(`
+  policy_mode = "warn"
+`),
+			ExpectError: regexp.MustCompile(`expected policy_mode to be one of \[filter reject\]`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_source(t *testing.T) {
+	dir := testAccSnapshot(t, "v1/amazon/eu-central-1/images/active.json")
+	config := func(source string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  # not reachable, all the documents come from the source
+  api_endpoint = "http://127.0.0.1:1"
+
+  source {
+%s
+  }
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, source)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(fmt.Sprintf(`
+    type = "directory"
+    path = "%s"
+`, dir)),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config(`
+    type = "directory"
+`),
+			ExpectError: regexp.MustCompile(`source: path is required by the directory source`),
+		},
+		testAccStep{
+			Config: config(fmt.Sprintf(`
+    type = "archive"
+    path = "%s"
+`, filepath.Join(dir, "snapshot.rar"))),
+			ExpectError: regexp.MustCompile(`unsupported archive`),
+		},
+		testAccStep{
+			Config: config(fmt.Sprintf(`
+    type = "directory"
+    path = "%s"
+`, t.TempDir())),
+			ExpectError: regexp.MustCompile(`v1/amazon/eu-central-1/images/active.json not found in directory`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_signedBundle(t *testing.T) {
+	dir := testAccSnapshot(t, "v1/amazon/eu-central-1/images/active.json")
+
+	public, private, err := bundle.GenerateKey()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	untrusted, _, err := bundle.GenerateKey()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	key, err := bundle.ParsePrivateKey(private)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, dir, key); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	config := func(keys ...[]byte) string {
+		quoted := make([]string, 0, len(keys))
+		for _, k := range keys {
+			quoted = append(quoted, fmt.Sprintf("%q", k))
+		}
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "http://127.0.0.1:1"
+
+  source {
+    type         = "archive"
+    path         = "%s"
+    trusted_keys = [%s]
+  }
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, archive, strings.Join(quoted, ", "))
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(untrusted, public),
+			Check:  testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config:      config(untrusted),
+			ExpectError: regexp.MustCompile(`is not made by a trusted key`),
+		},
+		testAccStep{
+			Config:      config([]byte("not a key")),
+			ExpectError: regexp.MustCompile(`expected a PEM encoded PUBLIC KEY block`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_idStability(t *testing.T) {
+	var first, second string
+
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15"
+}
+`),
+			Check: testAccStoreID(testAccImageIDs, &first),
+		},
+		testAccStep{
+			// same query, written differently
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  name_regex     = "suse-sles-15"
+  sort_ascending = false
+  state          = "active"
+  region         = "eu-central-1"
+  cloud          = "amazon"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccStoreID(testAccImageIDs, &second),
+				func(testAccState) error {
+					if first != second {
+						return fmt.Errorf("The id changed from %s to %s", first, second)
+					}
+					return nil
+				},
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-12"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccStoreID(testAccImageIDs, &second),
+				func(testAccState) error {
+					if first == second {
+						return fmt.Errorf("A different query should have a different id")
+					}
+					return nil
+				},
+			),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_refresh(t *testing.T) {
+	defer testAccResetCatalog(t)
+
+	config := testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`)
+
+	testAccTest(t,
+		testAccStep{
+			Config: config,
+			Check:  testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			// a new image is published
+			PreConfig: func() { testAccPublishImage(t) },
+			Config:    config,
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{
+				"ami-0c0ffee0c0ffee000",
+				"ami-0f9515259be7cd031",
+			}),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_lockFile(t *testing.T) {
+	defer testAccResetCatalog(t)
+
+	lockFile := filepath.Join(t.TempDir(), "images.lock.json")
+	config := func(refresh bool) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint      = "%s"
+  lock_file         = "%s"
+  refresh_lock_file = %v
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, testAccServer.URL, lockFile, refresh)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(false),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+				func(testAccState) error {
+					if _, err := os.Stat(lockFile); err != nil {
+						return fmt.Errorf("The lock file has not been written: %v", err)
+					}
+					return nil
+				},
+			),
+		},
+		testAccStep{
+			// a new image is published, the locked result is returned
+			PreConfig: func() { testAccPublishImage(t) },
+			Config:    config(false),
+			Check:     testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config(true),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{
+				"ami-0c0ffee0c0ffee000",
+				"ami-0f9515259be7cd031",
+			}),
+		},
+		testAccStep{
+			// the refreshed entry is now locked, even though one of the
+			// images is not published anymore
+			PreConfig: func() { testAccResetCatalog(t) },
+			Config:    config(false),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{
+					"ami-0c0ffee0c0ffee000",
+					"ami-0f9515259be7cd031",
+				}),
+				testAccCheckAttr(testAccImageIDs, "images.1.name", "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageIDs, "warnings.#", "1"),
+				testAccCheckAttr(testAccImageIDs, "warnings.0", "locked image ami-0c0ffee0c0ffee000 is not published anymore"),
+			),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_lockFileChecks(t *testing.T) {
+	defer testAccResetCatalog(t)
+
+	lockFile := filepath.Join(t.TempDir(), "images.lock.json")
+	config := func(provider, state string, explain bool) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "%s"
+  lock_file    = "%s"
+%s
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+  state      = "%s"
+  explain    = %v
+}
+`, testAccServer.URL, lockFile, provider, state, explain)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config("", "active", false),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+				testAccCheckAttr(testAccImageIDs, "warnings.#", "0"),
+			),
+		},
+		testAccStep{
+			// the locked images are described like the resolved ones
+			PreConfig: func() { testAccPublishImage(t) },
+			Config:    config("", "active", true),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+				testAccCheckAttr(testAccImageIDs, "images.#", "1"),
+				testAccCheckAttr(testAccImageIDs, "images.0.name", "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImageIDs, "served_by", testAccServer.URL),
+				testAccCheckAttr(testAccImageIDs, "explanation.0.id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImageIDs, "explanation.0.selected", "true"),
+				testAccCheckAttr(testAccImageIDs, "explanation.1.id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImageIDs, "explanation.1.selected", "false"),
+				testAccCheckAttr(testAccImageIDs, "explanation.1.criterion", "lock_file"),
+			),
+		},
+		testAccStep{
+			// the policy tightened after the lock file was written applies
+			Config: config(`
+  allowed_licenses = ["payg"]
+  policy_mode      = "reject"
+`, "active", false),
+			ExpectError: regexp.MustCompile(`lock_file: images violating the selection policy:\s+image suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64 \(ami-0f9515259be7cd031\) violates the allowed_licenses policy rule`),
+		},
+		testAccStep{
+			Config: config(`
+  allowed_licenses = ["payg"]
+`, "active", false),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{}),
+				testAccCheckAttr(testAccImageIDs, "images.#", "0"),
+			),
+		},
+		testAccStep{
+			Config: config("", "deprecated", false),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-01c2d3e4f5a6b7c8d"}),
+				testAccCheckAttr(testAccImageIDs, "warnings.#", "1"),
+			),
+		},
+		testAccStep{
+			// the locked deprecated images are reported as well
+			Config:      config("  fail_on_deprecated_images = true", "deprecated", false),
+			ExpectError: regexp.MustCompile(`deprecated images selected:\s+image suse-sles-15-sp1-byos-v20190301`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_serviceErrors(t *testing.T) {
+	defer testAccServer.ResetHooks()
+
+	config := func(cloud string) string {
+		return testAccProviderConfig(fmt.Sprintf(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "%s"
+  region = "eu-central-1"
+}
+`, cloud))
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config:      config("hpcloud"),
+			ExpectError: regexp.MustCompile(`cloud: unknown cloud framework "hpcloud", expected one of alibaba, amazon, google, microsoft, oracle`),
+		},
+		testAccStep{
+			// known by the provider, not by the fake info service
+			Config:      config("google"),
+			ExpectError: regexp.MustCompile(`cloud: unknown cloud framework "google", expected one of alibaba, amazon, microsoft:`),
+		},
+		testAccStep{
+			PreConfig: func() {
+				testAccServer.AddHook(fake.WithStatus("/v1/amazon/", http.StatusServiceUnavailable))
+			},
+			Config:      config("amazon"),
+			ExpectError: regexp.MustCompile(`the info service is not available, retry later .*: unexpected HTTP status 503`),
+		},
+		testAccStep{
+			PreConfig: func() {
+				testAccServer.ResetHooks()
+				testAccServer.AddHook(fake.WithMalformedPayload("/v1/amazon/"))
+			},
+			Config:      config("amazon"),
+			ExpectError: regexp.MustCompile(`the info service replied with an invalid document`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_failover(t *testing.T) {
+	// the endpoint hangs until the request is cancelled
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	config := func(endpoints string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoints = [%s]
+  http_timeout  = "500ms"
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, endpoints)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			// the first endpoint is not reachable
+			Config: config(fmt.Sprintf(`"http://127.0.0.1:1", "%s"`, testAccServer.URL)),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+				testAccCheckAttr(testAccImageIDs, "served_by", testAccServer.URL),
+			),
+		},
+		testAccStep{
+			Config: config(fmt.Sprintf(`"%s", "%s"`, hanging.URL, testAccServer.URL)),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+				testAccCheckAttr(testAccImageIDs, "served_by", testAccServer.URL),
+			),
+		},
+		testAccStep{
+			Config:      config(`"http://127.0.0.1:1", "http://127.0.0.1:2"`),
+			ExpectError: regexp.MustCompile(`all the sources failed:\s+http://127.0.0.1:1: `),
+		},
+		testAccStep{
+			Config:      config(`"ftp://mirror.example.com"`),
+			ExpectError: regexp.MustCompile(`expected "api_endpoints.0" to have a url with schema of: "http,https"`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_explain(t *testing.T) {
+	config := func(preload bool, mode string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint          = "%s"
+  preload_catalog       = %v
+  allowed_architectures = ["x86_64"]
+  policy_mode           = "%s"
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*"
+  explain    = true
+}
+`, testAccServer.URL, preload, mode)
+	}
+
+	check := testAccComposeCheck(
+		testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		testAccCheckAttr(testAccImageIDs, "explanation.#", "24"),
+		testAccCheckAttr(testAccImageIDs, "explanation.0.id", "ami-0f9515259be7cd031"),
+		testAccCheckAttr(testAccImageIDs, "explanation.0.selected", "true"),
+		testAccCheckAttr(testAccImageIDs, "explanation.0.criterion", ""),
+		testAccCheckAttr(testAccImageIDs, "explanation.1.id", "ami-08d7e80118e53e581"),
+		testAccCheckAttr(testAccImageIDs, "explanation.1.selected", "false"),
+		testAccCheckAttr(testAccImageIDs, "explanation.1.criterion", "policy"),
+		testAccCheckAttr(testAccImageIDs, "explanation.1.reason",
+			`allowed_architectures rule: architecture "arm64" is not one of x86_64`),
+		testAccCheckAttr(testAccImageIDs, "explanation.2.id", "ami-082bfb28e7de47e17"),
+		testAccCheckAttr(testAccImageIDs, "explanation.2.criterion", "name_regex"),
+		testAccCheckAttr(testAccImageIDs, "explanation.2.reason", `name does not match "suse-sles-15-sp1-byos.*"`),
+		testAccCheckAttr(testAccImageIDs, "explanation.22.id", "ami-01c2d3e4f5a6b7c8d"),
+		testAccCheckAttr(testAccImageIDs, "explanation.22.criterion", "state"),
+		testAccCheckAttr(testAccImageIDs, "explanation.22.reason", `state "deprecated" is not "active"`),
+	)
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(false, "filter"),
+			Check:  check,
+		},
+		testAccStep{
+			Config: config(true, "filter"),
+			Check:  check,
+		},
+		// the explanation is part of the error, the attributes of a failed
+		// read are not stored
+		testAccStep{
+			Config: config(false, "reject"),
+			ExpectError: regexp.MustCompile(`(?s)violates the allowed_architectures policy rule.*explanation:\s+` +
+				`suse-sles-15-sp1-byos-v\d+-hvm-ssd-x86_64 \(ami-0f9515259be7cd031\): selected\s+` +
+				`suse-sles-15-sp1-byos-v\d+-hvm-ssd-arm64 \(ami-08d7e80118e53e581\): excluded by policy, ` +
+				`allowed_architectures rule: architecture "arm64" is not one of x86_64`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_waitFor(t *testing.T) {
+	defer testAccResetCatalog(t)
+
+	now := time.Now()
+	polls := 0
+	timeNow = func() time.Time { return now }
+	timeSleep = func(d time.Duration) {
+		now = now.Add(d)
+		polls++
+		// the image is published while waiting
+		if polls == 2 {
+			testAccPublishImage(t)
+		}
+	}
+	defer func() {
+		timeNow = time.Now
+		timeSleep = time.Sleep
+	}()
+
+	config := func(preload bool, waitFor string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint    = "%s"
+  preload_catalog = %v
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+
+  wait_for {
+%s
+  }
+}
+`, testAccServer.URL, preload, waitFor)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(false, `
+    min_count     = 2
+    poll_interval = "1m"
+    timeout       = "1h"
+`),
+			Check: testAccComposeCheck(
+				testAccCheckImageIDs(testAccImageIDs, []string{"ami-0c0ffee0c0ffee000", "ami-0f9515259be7cd031"}),
+				func(testAccState) error {
+					if polls != 2 {
+						return fmt.Errorf("Unexpected number of polls. Got %d, expected %d", polls, 2)
+					}
+					return nil
+				},
+			),
+		},
+		testAccStep{
+			PreConfig: func() {
+				testAccResetCatalog(t)
+				polls = 0
+			},
+			Config: config(true, `
+    min_count     = 2
+    poll_interval = "1m"
+`),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-0c0ffee0c0ffee000", "ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config(false, `
+    min_count     = 5
+    poll_interval = "1m"
+    timeout       = "5m"
+`),
+			ExpectError: regexp.MustCompile(`wait_for: timed out after 5m0s waiting for at least 5 images, 2 found:\s+` +
+				`suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64 \(ami-0c0ffee0c0ffee000, published on 2019-10-10\)`),
+		},
+		testAccStep{
+			// the errors of the configuration are not retried
+			Config: testAccProviderConfig(`
+data "susepubliccloud_image_ids" "test" {
+  cloud  = "amazon"
+  region = "mars-north-1"
+
+  wait_for {}
+}
+`),
+			ExpectError: regexp.MustCompile(`region: unknown region`),
+		},
+		testAccStep{
+			Config: config(false, `
+    poll_interval = "soon"
+`),
+			ExpectError: regexp.MustCompile(`wait_for.0.poll_interval: time: invalid duration "soon"`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_waitForFatal(t *testing.T) {
+	defer testAccServer.ResetHooks()
+
+	// the queries failing for good must not be retried
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	timeSleep = func(d time.Duration) {
+		now = now.Add(d)
+		t.Errorf("Unexpected retry of the query")
+	}
+	defer func() {
+		timeNow = time.Now
+		timeSleep = time.Sleep
+	}()
+
+	config := func(policy string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "%s"
+%s
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-.*-hvm-ssd"
+
+  wait_for {
+    poll_interval = "1s"
+    timeout       = "1m"
+  }
+}
+`, testAccServer.URL, policy)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config: config(`
+  allowed_architectures = ["arm64"]
+  policy_mode           = "reject"
+`),
+			ExpectError: regexp.MustCompile(`images violating the selection policy:\s+image suse-sles-15-sp1-\S+-x86_64 ` +
+				`\(ami-\w+\) violates the allowed_architectures policy rule`),
+		},
+		testAccStep{
+			PreConfig: func() {
+				testAccServer.AddHook(fake.WithMalformedPayload("/v1/amazon/"))
+			},
+			Config:      config(""),
+			ExpectError: regexp.MustCompile(`the info service replied with an invalid document`),
+		},
+	)
+}
+
+func TestAccDataSourceImageIDs_xmlFormat(t *testing.T) {
+	config := func(format string, preload bool) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint    = "%s"
+  api_format      = "%s"
+  preload_catalog = %v
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, testAccServer.URL, format, preload)
+	}
+
+	requested := len(testAccServer.Requests())
+	testAccTest(t,
+		testAccStep{
+			Config: config("xml", false),
+			Check:  testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config("xml", true),
+			Check:  testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config:      config("yaml", false),
+			ExpectError: regexp.MustCompile(`expected api_format to be one of \[json xml\]`),
+		},
+	)
+
+	requests := testAccServer.Requests()[requested:]
+	if len(requests) == 0 {
+		t.Fatalf("Expected the info service to be queried")
+	}
+	for _, r := range requests {
+		if !strings.Contains(r, ".xml") {
+			t.Fatalf("Unexpected request of a JSON document %s", r)
+		}
+	}
+}
+
+func TestAccDataSourceImageIDs_auth(t *testing.T) {
+	testAccServer.AddHook(fake.WithRequiredHeader("Authorization", "Bearer t0ken"))
+	defer testAccServer.ResetHooks()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("t0ken\n"), 0600); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	config := func(auth string) string {
+		return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "%s"
+%s
+}
+
+data "susepubliccloud_image_ids" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+}
+`, testAccServer.URL, auth)
+	}
+
+	testAccTest(t,
+		testAccStep{
+			Config:      config(""),
+			ExpectError: regexp.MustCompile(`unexpected HTTP status 401`),
+		},
+		testAccStep{
+			Config: config(`
+  api_token   = "t0ken"
+  api_headers = { "X-Mirror-Key" = "k3y" }
+`),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config(fmt.Sprintf(`
+  api_token_file = "%s"
+`, tokenFile)),
+			Check: testAccCheckImageIDs(testAccImageIDs, []string{"ami-0f9515259be7cd031"}),
+		},
+		testAccStep{
+			Config: config(`
+  api_token    = "t0ken"
+  api_username = "user"
+`),
+			ExpectError: regexp.MustCompile(`bearer token and basic authentication cannot be used together`),
+		},
+		testAccStep{
+			Config: config(fmt.Sprintf(`
+  api_token      = "t0ken"
+  api_token_file = "%s"
+`, tokenFile)),
+			ExpectError: regexp.MustCompile(`api_token and api_token_file cannot be used together`),
+		},
+		testAccStep{
+			Config: config(`
+  client_certificate_file = "client.pem"
+`),
+			ExpectError: regexp.MustCompile(`both a client certificate and a client key are required`),
+		},
+	)
+
+	if !strings.Contains(logs.String(), "[DEBUG] Reading image IDs") {
+		t.Fatalf("Expected the query to be logged")
+	}
+	for _, secret := range []string{"t0ken", "k3y"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Fatalf("Unexpected secret %s inside of the logs", secret)
+		}
+	}
+}
+
+// testAccCheckImageIDs checks the value of the ids attribute
+func testAccCheckImageIDs(name string, expected []string) testAccCheckFunc {
+	checks := []testAccCheckFunc{
+		testAccCheckAttr(name, "ids.#", fmt.Sprintf("%d", len(expected))),
+	}
+	for i, id := range expected {
+		checks = append(checks, testAccCheckAttr(name, fmt.Sprintf("ids.%d", i), id))
+	}
+
+	return testAccComposeCheck(checks...)
+}
+
+// testAccStoreID stores the id of the data source into id
+func testAccStoreID(name string, id *string) testAccCheckFunc {
+	return func(s testAccState) error {
+		attributes, ok := s[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+		if attributes["id"] == "" {
+			return fmt.Errorf("No id set for %s", name)
+		}
+		*id = attributes["id"]
+		return nil
+	}
+}
+
+// testAccResetCatalog restores the data served by the fake info service
+func testAccResetCatalog(t *testing.T) {
+	catalog, err := testAccCatalog()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	testAccServer.SetCatalog(catalog)
+}
+
+// testAccPublishImage adds a newer SLES 15 SP1 BYOS image to the fake info
+// service
+func testAccPublishImage(t *testing.T) {
+	catalog, err := testAccCatalog()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	catalog.Images["amazon"] = append(catalog.Images["amazon"], images.Image{
+		Name:        "suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64",
+		State:       "active",
+		PublishedOn: "20191010",
+		Region:      "eu-central-1",
+		ID:          "ami-0c0ffee0c0ffee000",
+	})
+	catalog.DataVersion["amazon"] = "2"
+	testAccServer.SetCatalog(catalog)
+}
+
+// testAccSnapshot copies the given documents of the fake info service into
+// a temporary directory, using the layout of the API paths
+func testAccSnapshot(t *testing.T, paths ...string) string {
+	dir := t.TempDir()
+
+	for _, p := range paths {
+		resp, err := http.Get(testAccServer.URL + "/" + p)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if e := resp.Body.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := os.WriteFile(name, data, 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestImageIDsID(t *testing.T) {
+	// the search criteria of the first releases of the provider
+	type baselineParams struct {
+		APIEndpoint   string
+		APIVersion    string
+		Cloud         string
+		NameRegex     string
+		Region        string
+		SortAscending bool
+		State         string
+	}
+
+	params := images.SearchParams{
+		Cloud:     "amazon",
+		Region:    "eu-central-1",
+		State:     "active",
+		NameRegex: "suse-sles-15.*",
+	}
+	baseline := baselineParams{
+		Cloud:     params.Cloud,
+		Region:    params.Region,
+		State:     params.State,
+		NameRegex: params.NameRegex,
+	}
+	expected := fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("%+v", baseline)))
+	if id := imageIDsID(params); id != expected {
+		t.Fatalf("Unexpected ID. Got %s, expected %s", id, expected)
+	}
+
+	// the settings of the provider are not part of the ID
+	withSource := params
+	withSource.APIEndpoint = "http://localhost"
+	withSource.Source = images.NewHTTPSource("http://localhost")
+	if id := imageIDsID(withSource); id != expected {
+		t.Fatalf("Unexpected ID. Got %s, expected %s", id, expected)
+	}
+
+	withFilter := params
+	withFilter.Filter = `product == "sles"`
+	if id := imageIDsID(withFilter); id == expected {
+		t.Fatalf("Unexpected ID. Got %s, expected a different one", id)
+	}
+}
@@ -0,0 +1,78 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"strings"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataSourceSUSEPublicCloudProductLifecycle() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceSUSEPublicCloudProductLifecycleRead,
+		Schema: map[string]*schema.Schema{
+			"product": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"variant": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"version": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"sp": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"general_support_ends_on": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ltss_ends_on": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"table_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceSUSEPublicCloudProductLifecycleRead(d *schema.ResourceData, meta interface{}) error {
+	product := d.Get("product").(string)
+	variant := d.Get("variant").(string)
+	version := d.Get("version").(int)
+	sp := d.Get("sp").(int)
+
+	lifecycle, ok := images.LookupLifecycle(product, variant, version, sp)
+	if !ok {
+		known := make([]string, 0)
+		for _, l := range images.Lifecycles() {
+			known = append(known, l.String())
+		}
+		return fmt.Errorf("no lifecycle is known for %s, the table %s covers: %s",
+			images.Lifecycle{Product: product, Variant: variant, Version: version, SP: sp},
+			images.LifecycleTableVersion(), strings.Join(known, ", "))
+	}
+
+	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("%s/%s", lifecycle, images.LifecycleTableVersion()))))
+
+	if err := d.Set("general_support_ends_on", lifecycle.GeneralSupportEnds); err != nil {
+		return err
+	}
+	if err := d.Set("ltss_ends_on", lifecycle.LTSSEnds); err != nil {
+		return err
+	}
+	return d.Set("table_version", images.LifecycleTableVersion())
+}
@@ -0,0 +1,53 @@
+package susepubliccloud
+
+import (
+	"regexp"
+	"testing"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+const testAccProductLifecycle = "data.susepubliccloud_product_lifecycle.test"
+
+func TestAccDataSourceProductLifecycle_basic(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_product_lifecycle" "test" {
+  product = "sles"
+  version = 15
+  sp      = 5
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccProductLifecycle, "general_support_ends_on", "2024-12-31"),
+				testAccCheckAttr(testAccProductLifecycle, "ltss_ends_on", "2027-12-31"),
+				testAccCheckAttr(testAccProductLifecycle, "table_version", images.LifecycleTableVersion()),
+			),
+		},
+		testAccStep{
+			// the variants share the general support of the product, not
+			// its LTSS
+			Config: testAccProviderConfig(`
+data "susepubliccloud_product_lifecycle" "test" {
+  product = "sles"
+  variant = "sap"
+  version = 15
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccProductLifecycle, "general_support_ends_on", "2019-12-31"),
+				testAccCheckAttr(testAccProductLifecycle, "ltss_ends_on", ""),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_product_lifecycle" "test" {
+  product = "sles"
+  version = 16
+}
+`),
+			ExpectError: regexp.MustCompile(`no lifecycle is known for sles 16, the table .* covers: sles 11 SP4, `),
+		},
+	)
+}
@@ -0,0 +1,240 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"strings"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Defaults of the min_prefix_length block, the shortest CIDR blocks produced
+// by max_rules
+const (
+	defaultMinIPv4PrefixLength = 16
+	defaultMinIPv6PrefixLength = 32
+)
+
+func dataSourceSUSEPublicCloudServerCIDRs() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceSUSEPublicCloudServerCIDRsRead,
+		Schema: map[string]*schema.Schema{
+			"cloud": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateCloud,
+			},
+			"region": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"types": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(images.ValidServerTypes, false),
+				},
+			},
+			"max_rules": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"min_prefix_length": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ipv4": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      defaultMinIPv4PrefixLength,
+							ValidateFunc: validation.IntBetween(0, 32),
+						},
+						"ipv6": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      defaultMinIPv6PrefixLength,
+							ValidateFunc: validation.IntBetween(0, 128),
+						},
+					},
+				},
+			},
+			"servers": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {Type: schema.TypeString, Computed: true},
+						"ipv4_cidrs": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"ipv6_cidrs": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"ipv4_cidrs": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"ipv6_cidrs": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"canonical_region": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// serverTypes returns the sorted server types set by the types argument,
+// all of them when it is empty
+func serverTypes(d *schema.ResourceData) []string {
+	set := make(map[string]bool)
+	for _, t := range d.Get("types").([]interface{}) {
+		set[t.(string)] = true
+	}
+	if len(set) == 0 {
+		for _, t := range images.ValidServerTypes {
+			set[t] = true
+		}
+	}
+
+	types := make([]string, 0, len(set))
+	for t := range set {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// prefixLimits holds the summarization settings of the CIDR blocks
+type prefixLimits struct {
+	MaxRules int
+	MinIPv4  int
+	MinIPv6  int
+}
+
+// expandPrefixLimits returns the settings of max_rules and of the
+// min_prefix_length block
+func expandPrefixLimits(d schemaGetter) prefixLimits {
+	limits := prefixLimits{
+		MaxRules: d.Get("max_rules").(int),
+		MinIPv4:  defaultMinIPv4PrefixLength,
+		MinIPv6:  defaultMinIPv6PrefixLength,
+	}
+	v, ok := d.GetOk("min_prefix_length")
+	if !ok || len(v.([]interface{})) == 0 {
+		return limits
+	}
+	block, ok := v.([]interface{})[0].(map[string]interface{})
+	if !ok {
+		// all the arguments of the block have been left to their defaults
+		return limits
+	}
+	if n, ok := block["ipv4"].(int); ok {
+		limits.MinIPv4 = n
+	}
+	if n, ok := block["ipv6"].(int); ok {
+		limits.MinIPv6 = n
+	}
+	return limits
+}
+
+// flattenPrefixes summarizes the prefixes into at most MaxRules entries,
+// when it is positive, and formats them. The error names the list that
+// cannot fit.
+func flattenPrefixes(name string, prefixes []netip.Prefix, limits prefixLimits) ([]string, error) {
+	summarized, err := images.SummarizePrefixes(prefixes, limits.MaxRules, limits.MinIPv4, limits.MinIPv6)
+	if err != nil {
+		return nil, fmt.Errorf("max_rules: the %s cannot fit into %d rules, raise max_rules or lower min_prefix_length: %w",
+			name, limits.MaxRules, err)
+	}
+	cidrs := make([]string, 0, len(summarized))
+	for _, p := range summarized {
+		cidrs = append(cidrs, p.String())
+	}
+	return cidrs, nil
+}
+
+func dataSourceSUSEPublicCloudServerCIDRsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	cloud := d.Get("cloud").(string)
+	region := config.canonicalRegion(cloud, d.Get("region").(string))
+	types := serverTypes(d)
+	limits := expandPrefixLimits(d)
+
+	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("cidrs/%s/%s/%s/%+v",
+		cloud, region, strings.Join(types, ","), limits))))
+	if err := d.Set("canonical_region", region); err != nil {
+		return err
+	}
+
+	servers := make([]interface{}, 0, len(types))
+	var allIPv4, allIPv6 []netip.Prefix
+	for _, t := range types {
+		params := images.ServerSearchParams{
+			Cloud:  cloud,
+			Region: region,
+			Type:   t,
+		}
+		log.Printf("[DEBUG] Reading servers: %+v", params)
+		found, err := config.searchServers(params)
+		if err != nil {
+			return err
+		}
+
+		ipv4, ipv6, err := images.ServerPrefixes(found)
+		if err != nil {
+			return err
+		}
+		allIPv4 = append(allIPv4, ipv4...)
+		allIPv6 = append(allIPv6, ipv6...)
+
+		ipv4CIDRs, err := flattenPrefixes(fmt.Sprintf("IPv4 blocks of the %s servers", t), ipv4, limits)
+		if err != nil {
+			return err
+		}
+		ipv6CIDRs, err := flattenPrefixes(fmt.Sprintf("IPv6 blocks of the %s servers", t), ipv6, limits)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, map[string]interface{}{
+			"type":       t,
+			"ipv4_cidrs": ipv4CIDRs,
+			"ipv6_cidrs": ipv6CIDRs,
+		})
+	}
+
+	if err := d.Set("servers", servers); err != nil {
+		return err
+	}
+	ipv4CIDRs, err := flattenPrefixes("IPv4 blocks", allIPv4, limits)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("ipv4_cidrs", ipv4CIDRs); err != nil {
+		return err
+	}
+	ipv6CIDRs, err := flattenPrefixes("IPv6 blocks", allIPv6, limits)
+	if err != nil {
+		return err
+	}
+	return d.Set("ipv6_cidrs", ipv6CIDRs)
+}
@@ -0,0 +1,120 @@
+package susepubliccloud
+
+import (
+	"regexp"
+	"testing"
+)
+
+const testAccServerCIDRs = "data.susepubliccloud_server_cidrs.test"
+
+func TestAccDataSourceServerCIDRs_basic(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud  = "amazon"
+  region = "Europe (Frankfurt)"
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccServerCIDRs, "canonical_region", "eu-central-1"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.#", "3"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.type", "regionserver"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.#", "2"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.0", "18.156.115.8/31"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.1", "52.28.243.25/32"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.#", "2"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.0", "2a05:d014:cea:a201::4/127"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.1", "2a05:d014:cea:a202::5/128"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.1.type", "smt"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.1.ipv4_cidrs.0", "3.124.39.111/32"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.1.ipv6_cidrs.#", "0"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.2.type", "update"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.2.ipv4_cidrs.#", "0"),
+				testAccCheckAttr(testAccServerCIDRs, "ipv4_cidrs.#", "3"),
+				testAccCheckAttr(testAccServerCIDRs, "ipv4_cidrs.0", "3.124.39.111/32"),
+				testAccCheckAttr(testAccServerCIDRs, "ipv6_cidrs.#", "2"),
+			),
+		},
+		testAccStep{
+			// the servers of all the regions don't fit into a /16
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud     = "amazon"
+  types     = ["regionserver"]
+  max_rules = 1
+}
+`),
+			ExpectError: regexp.MustCompile(`max_rules: the IPv4 blocks of the regionserver servers cannot fit into 1 rules, ` +
+				`raise max_rules or lower min_prefix_length: \d+ prefixes cannot be summarized into 1 ` +
+				`without IPv4 prefixes shorter than /16 or IPv6 prefixes shorter than /32`),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud     = "amazon"
+  types     = ["regionserver"]
+  max_rules = 1
+
+  min_prefix_length {
+    ipv4 = 0
+    ipv6 = 0
+  }
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccServerCIDRs, "canonical_region", ""),
+				testAccCheckAttr(testAccServerCIDRs, "servers.#", "1"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.#", "1"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.0", "0.0.0.0/2"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.#", "1"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.0", "2000::/4"),
+				testAccCheckAttr(testAccServerCIDRs, "ipv4_cidrs.0", "0.0.0.0/2"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud     = "amazon"
+  region    = "eu-central-1"
+  types     = ["regionserver"]
+  max_rules = 2
+}
+`),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv4_cidrs.#", "2"),
+				testAccCheckAttr(testAccServerCIDRs, "servers.0.ipv6_cidrs.#", "2"),
+			),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud = "amazon"
+  types = ["proxy"]
+}
+`),
+			ExpectError: regexp.MustCompile(`expected types.0 to be one of`),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud = "amazon"
+
+  min_prefix_length {
+    ipv4 = 33
+  }
+}
+`),
+			ExpectError: regexp.MustCompile(`expected min_prefix_length.0.ipv4 to be in the range \(0 - 32\)`),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+data "susepubliccloud_server_cidrs" "test" {
+  cloud  = "amazon"
+  region = "mars-north-1"
+}
+`),
+			ExpectError: regexp.MustCompile(`region: unknown region "mars-north-1"`),
+		},
+	)
+}
@@ -0,0 +1,48 @@
+package susepubliccloud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// describeError maps the errors of the info-service package to diagnostics
+// naming the argument or the setting to be fixed, the original error is
+// wrapped
+func describeError(err error, params images.SearchParams) error {
+	var httpErr *images.HTTPError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, images.ErrUnknownProvider):
+		return fmt.Errorf("cloud: %w", err)
+	case errors.Is(err, images.ErrUnknownRegion):
+		info := images.Cloud(params.Cloud).Info()
+		if info.RegionExample == "" {
+			return fmt.Errorf("region: %w", err)
+		}
+		return fmt.Errorf("region: %w (the regions of %s are named like %q)",
+			err, info.DisplayName, info.RegionExample)
+	case errors.Is(err, images.ErrInvalidFilter):
+		return fmt.Errorf("filter: %w", err)
+	case errors.Is(err, images.ErrInvalidNameRegex):
+		return fmt.Errorf("name_regex: %w", err)
+	case errors.Is(err, images.ErrDecode):
+		return fmt.Errorf("the info service replied with an invalid document, "+
+			"check that api_endpoint points to an instance of the info service: %w", err)
+	case errors.As(err, &httpErr):
+		switch {
+		case httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("the info service refused the credentials, "+
+				"check the authentication options of the provider: %w", err)
+		case httpErr.StatusCode >= 500:
+			return fmt.Errorf("the info service is not available, "+
+				"retry later or list a mirror inside of api_endpoints: %w", err)
+		}
+	}
+
+	return err
+}
@@ -1,7 +1,18 @@
 package susepubliccloud
 
 import (
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/bundle"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
 
@@ -9,17 +20,466 @@ import (
 func Provider() terraform.ResourceProvider {
 	// The actual provider
 	return &schema.Provider{
-		Schema: map[string]*schema.Schema{},
+		Schema: map[string]*schema.Schema{
+			"api_endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_ENDPOINT", images.APIEndpoint),
+				Description: "Endpoint of the SUSE public cloud info service",
+			},
+			"api_endpoints": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+				},
+				Description: "Endpoints of the info service tried in order, replacing api_endpoint",
+			},
+			"failover_cooldown": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      images.DefaultFailoverCooldown.String(),
+				ValidateFunc: validateDuration,
+				Description:  "Amount of time a failing endpoint of api_endpoints is skipped",
+			},
+			"http_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      images.DefaultHTTPTimeout.String(),
+				ValidateFunc: validateDuration,
+				Description:  "Time limit of the requests to the info service",
+			},
+			"api_format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(images.FormatJSON),
+				ValidateFunc: validation.StringInSlice(images.ValidFormats, false),
+				Description:  "Format of the documents requested to the info service",
+			},
+			"source": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(sourceTypes, false),
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"trusted_keys": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validatePublicKey,
+							},
+						},
+					},
+				},
+				Description: "Snapshot of the info service used instead of api_endpoint",
+			},
+			"api_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_TOKEN", nil),
+				Description: "Bearer token sent to api_endpoint",
+			},
+			"api_token_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_TOKEN_FILE", nil),
+				Description: "File holding the bearer token sent to api_endpoint",
+			},
+			"api_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_USERNAME", nil),
+				Description: "Username of the HTTP basic authentication of api_endpoint",
+			},
+			"api_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_PASSWORD", nil),
+				Description: "Password of the HTTP basic authentication of api_endpoint",
+			},
+			"api_password_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_API_PASSWORD_FILE", nil),
+				Description: "File holding the password of the HTTP basic authentication of api_endpoint",
+			},
+			"api_headers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Sensitive:   true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Headers added to all the requests sent to api_endpoint",
+			},
+			"client_certificate_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_CLIENT_CERTIFICATE_FILE", nil),
+				Description: "PEM encoded client certificate presented to api_endpoint",
+			},
+			"client_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_CLIENT_KEY_FILE", nil),
+				Description: "PEM encoded key of client_certificate_file",
+			},
+			"ca_certificate_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SUSEPUBLICCLOUD_CA_CERTIFICATE_FILE", nil),
+				Description: "PEM encoded certificate authorities trusted when accessing api_endpoint",
+			},
+			"fail_on_deprecated_images": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Fail instead of warning when a data source selects deprecated images",
+			},
+			"preload_catalog": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Load the whole image catalog of a cloud once and answer all the queries from it",
+			},
+			"lock_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "JSON file recording the first resolution of each image query",
+			},
+			"refresh_lock_file": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Resolve again the queries recorded inside of the lock file and update them",
+			},
+			"allowed_licenses": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{images.LicenseBYOS, images.LicensePAYG}, false),
+				},
+				Description: "Licenses of the images that can be selected",
+			},
+			"allowed_architectures": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{"x86_64", "arm64"}, false),
+				},
+				Description: "Architectures of the images that can be selected",
+			},
+			"forbid_states": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(images.ValidImageStates, false),
+				},
+				Description: "States of the images that cannot be selected",
+			},
+			"max_image_age_days": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum age of the images that can be selected, in days since their publication",
+			},
+			"allowed_name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Regular expression matched by the names of the images that can be selected",
+			},
+			"policy_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      images.PolicyModeFilter,
+				ValidateFunc: validation.StringInSlice([]string{images.PolicyModeFilter, images.PolicyModeReject}, false),
+				Description:  "Whether the images violating the policy are filtered out or make the queries fail",
+			},
+		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"susepubliccloud_image_ids": dataSourceSUSEPublicCloudImageIDs(),
+			"susepubliccloud_image_ids":         dataSourceSUSEPublicCloudImageIDs(),
+			"susepubliccloud_image_families":    dataSourceSUSEPublicCloudImageFamilies(),
+			"susepubliccloud_image_equivalents": dataSourceSUSEPublicCloudImageEquivalents(),
+			"susepubliccloud_server_cidrs":      dataSourceSUSEPublicCloudServerCIDRs(),
+			"susepubliccloud_product_lifecycle": dataSourceSUSEPublicCloudProductLifecycle(),
 		},
 
-		ResourcesMap:  map[string]*schema.Resource{},
+		ResourcesMap: map[string]*schema.Resource{
+			"susepubliccloud_image_pin": resourceSUSEPublicCloudImagePin(),
+		},
 		ConfigureFunc: providerConfigure,
 	}
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	return nil, nil
+	config := Config{
+		APIEndpoint:            d.Get("api_endpoint").(string),
+		PreloadCatalog:         d.Get("preload_catalog").(bool),
+		FailOnDeprecatedImages: d.Get("fail_on_deprecated_images").(bool),
+		RefreshLockFile:        d.Get("refresh_lock_file").(bool),
+	}
+
+	source, err := providerSource(d)
+	if err != nil {
+		return nil, err
+	}
+	config.Source = source
+
+	auth, err := providerAuth(d)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]string, 0)
+	for _, v := range d.Get("api_endpoints").([]interface{}) {
+		endpoints = append(endpoints, v.(string))
+	}
+
+	format := images.Format(d.Get("api_format").(string))
+
+	if source != nil && (auth != nil || len(endpoints) > 0 || format != images.FormatJSON) {
+		return nil, fmt.Errorf("api_endpoints, api_format and the authentication options are only supported when accessing the info service, not by the %s source",
+			d.Get("source.0.type").(string))
+	}
+
+	// already validated by the schema
+	timeout, _ := time.ParseDuration(d.Get("http_timeout").(string))
+	client := &http.Client{}
+	if auth != nil {
+		if client, err = auth.Client(); err != nil {
+			return nil, err
+		}
+	}
+	client.Timeout = timeout
+
+	switch {
+	case len(endpoints) > 0:
+		failover := images.NewEndpointsSource(endpoints, client, auth)
+		for _, src := range failover.Sources {
+			src.(*images.HTTPSource).Format = format
+		}
+		// already validated by the schema
+		failover.Cooldown, _ = time.ParseDuration(d.Get("failover_cooldown").(string))
+		config.APIEndpoint = endpoints[0]
+		config.Source = failover
+	case source == nil && (auth != nil || format != images.FormatJSON || timeout != images.DefaultHTTPTimeout):
+		config.Source = &images.HTTPSource{Endpoint: config.APIEndpoint, Client: client, Auth: auth, Format: format}
+	}
+
+	if path, ok := d.GetOk("lock_file"); ok {
+		lockFile, err := images.LoadLockFile(path.(string))
+		if err != nil {
+			return nil, err
+		}
+		config.LockFile = lockFile
+	}
+
+	config.Policy = providerPolicy(d)
+
+	return &config, nil
+}
+
+// Types of the sources of the API documents
+const (
+	sourceHTTP      = "http"
+	sourceDirectory = "directory"
+	sourceArchive   = "archive"
+)
+
+var sourceTypes = []string{sourceHTTP, sourceDirectory, sourceArchive}
+
+// providerSource returns the source configured by the source block, nil
+// when the documents are fetched from api_endpoint
+func providerSource(d *schema.ResourceData) (images.Source, error) {
+	v, ok := d.GetOk("source")
+	if !ok || len(v.([]interface{})) == 0 || v.([]interface{})[0] == nil {
+		return nil, nil
+	}
+
+	block := v.([]interface{})[0].(map[string]interface{})
+	sourceType := block["type"].(string)
+	path := block["path"].(string)
+
+	trusted := make([]ed25519.PublicKey, 0)
+	for _, k := range block["trusted_keys"].([]interface{}) {
+		// already validated by the schema
+		key, _ := bundle.ParsePublicKey([]byte(k.(string)))
+		trusted = append(trusted, key)
+	}
+
+	if sourceType != sourceArchive && len(trusted) > 0 {
+		return nil, fmt.Errorf("source: trusted_keys is only supported by the %s source", sourceArchive)
+	}
+	if sourceType == sourceHTTP {
+		if path != "" {
+			return nil, fmt.Errorf("source: path is not supported by the %s source, use api_endpoint", sourceHTTP)
+		}
+		return nil, nil
+	}
+	if path == "" {
+		return nil, fmt.Errorf("source: path is required by the %s source", sourceType)
+	}
+
+	if sourceType == sourceArchive {
+		if len(trusted) == 0 {
+			return images.NewArchiveSource(path)
+		}
+		// the archive must be a bundle signed by one of the trusted keys
+		b, err := bundle.Open(path, trusted)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source: %s is not a directory", path)
+	}
+	return images.NewDirectorySource(path), nil
+}
+
+// providerAuth returns the credentials used to access api_endpoint, nil
+// when no authentication option is set
+func providerAuth(d *schema.ResourceData) (*images.Auth, error) {
+	auth := images.Auth{
+		Username:       d.Get("api_username").(string),
+		ClientCertFile: d.Get("client_certificate_file").(string),
+		ClientKeyFile:  d.Get("client_key_file").(string),
+		CAFile:         d.Get("ca_certificate_file").(string),
+	}
+
+	var err error
+	if auth.BearerToken, err = secret(d, "api_token"); err != nil {
+		return nil, err
+	}
+	if auth.Password, err = secret(d, "api_password"); err != nil {
+		return nil, err
+	}
+
+	if headers := d.Get("api_headers").(map[string]interface{}); len(headers) > 0 {
+		auth.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			auth.Headers[name] = value.(string)
+		}
+	}
+
+	if auth.BearerToken == "" && auth.Username == "" && auth.Password == "" &&
+		len(auth.Headers) == 0 && auth.ClientCertFile == "" &&
+		auth.ClientKeyFile == "" && auth.CAFile == "" {
+		return nil, nil
+	}
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// secret returns the value of the given argument, or the content of the file
+// referenced by its KEY_file variant. Setting both is an error.
+func secret(d *schema.ResourceData, key string) (string, error) {
+	value := d.Get(key).(string)
+	path := d.Get(key + "_file").(string)
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("%s and %s_file cannot be used together", key, key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error while reading %s_file: %v", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// validateDuration is a SchemaValidateFunc which tests if the provided value
+// is a positive duration, like "5m"
+func validateDuration(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+		return
+	}
+	if d <= 0 {
+		es = append(es, fmt.Errorf("%s: expected a positive duration, got %s", k, v))
+	}
+
+	return
+}
+
+// validatePublicKey is a SchemaValidateFunc which tests if the provided
+// value is a PEM encoded ed25519 public key
+func validatePublicKey(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := bundle.ParsePublicKey([]byte(v)); err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+	}
+
+	return
+}
+
+// providerPolicy returns the selection policy configured for the provider,
+// nil when no rule is set
+func providerPolicy(d *schema.ResourceData) *images.Policy {
+	policy := images.Policy{Mode: d.Get("policy_mode").(string)}
+	set := false
+
+	for key, rule := range map[string]*[]string{
+		"allowed_licenses":      &policy.AllowedLicenses,
+		"allowed_architectures": &policy.AllowedArchitectures,
+		"forbid_states":         &policy.ForbidStates,
+	} {
+		for _, v := range d.Get(key).(*schema.Set).List() {
+			*rule = append(*rule, v.(string))
+			set = true
+		}
+	}
+
+	if v, ok := d.GetOk("max_image_age_days"); ok {
+		policy.MaxImageAgeDays = v.(int)
+		set = true
+	}
+
+	if v, ok := d.GetOk("allowed_name_regex"); ok {
+		// already validated by the schema
+		policy.AllowedNameRegex = regexp.MustCompile(v.(string))
+		set = true
+	}
+
+	if !set {
+		return nil
+	}
+	return &policy
 }
@@ -0,0 +1,50 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service/fake"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// testAccServer is a fake info service, used by the acceptance tests
+// instead of the public instance of the service
+var testAccServer *fake.Server
+
+func TestMain(m *testing.M) {
+	catalog, err := testAccCatalog()
+	if err != nil {
+		log.Fatalf("cannot load the fixtures: %v", err)
+	}
+	testAccServer = fake.NewServer(catalog)
+
+	code := m.Run()
+	testAccServer.Close()
+	os.Exit(code)
+}
+
+// testAccCatalog returns the data served by the fake info service
+func testAccCatalog() (*fake.Catalog, error) {
+	return fake.LoadDir("testdata")
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+// testAccProviderConfig returns the given configuration pointing the
+// provider to the fake info service
+func testAccProviderConfig(config string) string {
+	return fmt.Sprintf(`
+provider "susepubliccloud" {
+  api_endpoint = "%s"
+}
+
+%s
+`, testAccServer.URL, config)
+}
@@ -0,0 +1,343 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func resourceSUSEPublicCloudImagePin() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceSUSEPublicCloudImagePinCreate,
+		Read:          resourceSUSEPublicCloudImagePinRead,
+		Update:        resourceSUSEPublicCloudImagePinUpdate,
+		Delete:        resourceSUSEPublicCloudImagePinDelete,
+		CustomizeDiff: resourceSUSEPublicCloudImagePinCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"filter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateFilter,
+			},
+			"cloud": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateCloud,
+			},
+			"region": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "active",
+				ValidateFunc: validateState,
+			},
+			"maintenance_window": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"schedule": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateCronSchedule,
+						},
+						"duration": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateWindowDuration,
+						},
+						"time_zone": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "UTC",
+							ValidateFunc: validateTimeZone,
+						},
+					},
+				},
+			},
+			"rotate_trigger": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"image_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"image_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"published_on": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"image_state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"deprecated_on": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"deleted_on": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"replacement_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"replacement_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"previous_image_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"previous_image_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// validateCronSchedule is a SchemaValidateFunc which tests if the provided
+// value is a valid cron-like schedule
+func validateCronSchedule(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := parseCronSchedule(v); err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+	}
+
+	return
+}
+
+// validateWindowDuration is a SchemaValidateFunc which tests if the provided
+// value is a valid duration of a maintenance window
+func validateWindowDuration(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := newMaintenanceWindow("* * * * *", v, "UTC"); err != nil {
+		es = append(es, fmt.Errorf("%s: %v", k, err))
+	}
+
+	return
+}
+
+// validateTimeZone is a SchemaValidateFunc which tests if the provided value
+// is a time zone known to the IANA database
+func validateTimeZone(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, err := time.LoadLocation(v); err != nil {
+		es = append(es, fmt.Errorf("%s: invalid time zone %q", k, v))
+	}
+
+	return
+}
+
+// pinnedImageMissing is the image_state of a pinned image that is not
+// published anymore by the info service
+const pinnedImageMissing = "missing"
+
+// isPinnedImageGone tells whether the pinned image cannot be used anymore,
+// the pin then moves regardless of the maintenance window
+func isPinnedImageGone(d schemaGetter) bool {
+	state := d.Get("image_state").(string)
+	return state == "deleted" || state == pinnedImageMissing
+}
+
+// resolvePinnedImage returns the newest image matching the query of the pin
+func resolvePinnedImage(d schemaGetter, meta interface{}) (images.Image, error) {
+	params := imageSearchParams(d)
+	params.Region = meta.(*Config).canonicalRegion(params.Cloud, params.Region)
+	log.Printf("[DEBUG] Resolving pinned image: %+v", params)
+
+	found, servedBy, err := meta.(*Config).searchImages(params)
+	if err != nil {
+		return images.Image{}, err
+	}
+	log.Printf("[DEBUG] Pinned image query served by %s", servedBy)
+	if len(found) == 0 {
+		return images.Image{}, fmt.Errorf("no image matches the query of the pin")
+	}
+
+	return found[0], nil
+}
+
+// isMaintenanceWindowOpen returns true when the maintenance window of the
+// pin is open. Pins without a window only move when rotate_trigger changes.
+func isMaintenanceWindowOpen(d schemaGetter, now time.Time) (bool, error) {
+	v, ok := d.GetOk("maintenance_window")
+	if !ok || len(v.([]interface{})) == 0 || v.([]interface{})[0] == nil {
+		return false, nil
+	}
+
+	w := v.([]interface{})[0].(map[string]interface{})
+	window, err := newMaintenanceWindow(
+		w["schedule"].(string),
+		w["duration"].(string),
+		w["time_zone"].(string))
+	if err != nil {
+		return false, err
+	}
+
+	return window.isOpen(now), nil
+}
+
+func resourceSUSEPublicCloudImagePinCreate(d *schema.ResourceData, meta interface{}) error {
+	image, err := resolvePinnedImage(d, meta)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", stringTohashcode(fmt.Sprintf("%+v/%d", imageSearchParams(d), timeNow().UnixNano()))))
+
+	if err := d.Set("previous_image_id", ""); err != nil {
+		return err
+	}
+	if err := d.Set("previous_image_name", ""); err != nil {
+		return err
+	}
+
+	return setPinnedImage(d, image)
+}
+
+// resourceSUSEPublicCloudImagePinRead refreshes the state, the deprecation
+// and the replacement of the pinned image, which stays pinned until the pin
+// moves
+func resourceSUSEPublicCloudImagePinRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	params := images.SearchParams{Cloud: d.Get("cloud").(string)}
+	params.Region = config.canonicalRegion(params.Cloud, d.Get("region").(string))
+	id := d.Get("image_id").(string)
+
+	image, ok, err := config.findImage(params, id, "")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("[WARN] The pinned image %s (%s) is not published anymore, the pin moves to the newest image",
+			d.Get("image_name").(string), id)
+		image = images.Image{
+			ID:          id,
+			Name:        d.Get("image_name").(string),
+			PublishedOn: d.Get("published_on").(string),
+			State:       pinnedImageMissing,
+		}
+	} else if warnings := deprecationWarnings([]images.Image{image}); len(warnings) > 0 {
+		log.Printf("[WARN] The pinned %s", warnings[0])
+	}
+
+	return setPinnedImage(d, image)
+}
+
+// resourceSUSEPublicCloudImagePinCustomizeDiff plans the move to the newest
+// image matching the query when the maintenance window is open or when the
+// rotate_trigger argument changes
+func resourceSUSEPublicCloudImagePinCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	open, err := isMaintenanceWindowOpen(d, timeNow())
+	if err != nil {
+		return err
+	}
+	if !open && !d.HasChange("rotate_trigger") && !isPinnedImageGone(d) {
+		return nil
+	}
+
+	image, err := resolvePinnedImage(d, meta)
+	if err != nil {
+		return err
+	}
+
+	current := d.Get("image_id").(string)
+	if image.ID == current {
+		return nil
+	}
+
+	log.Printf("[INFO] Moving the pin from image %s to image %s", current, image.ID)
+	for k, v := range map[string]string{
+		"image_id":            image.ID,
+		"image_name":          image.Name,
+		"published_on":        image.PublishedOn,
+		"image_state":         image.State,
+		"deprecated_on":       image.DeprecatedOn,
+		"deleted_on":          image.DeletedOn,
+		"replacement_id":      image.ReplacementID,
+		"replacement_name":    image.ReplacementName,
+		"previous_image_id":   current,
+		"previous_image_name": d.Get("image_name").(string),
+	} {
+		if err := d.SetNew(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceSUSEPublicCloudImagePinUpdate(d *schema.ResourceData, meta interface{}) error {
+	// the move to a new image, if any, has been planned by CustomizeDiff and
+	// is stored into the state as it is
+	return nil
+}
+
+func resourceSUSEPublicCloudImagePinDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
+
+func setPinnedImage(d *schema.ResourceData, image images.Image) error {
+	for k, v := range map[string]string{
+		"image_id":         image.ID,
+		"image_name":       image.Name,
+		"published_on":     image.PublishedOn,
+		"image_state":      image.State,
+		"deprecated_on":    image.DeprecatedOn,
+		"deleted_on":       image.DeletedOn,
+		"replacement_id":   image.ReplacementID,
+		"replacement_name": image.ReplacementName,
+	} {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+const testAccImagePin = "susepubliccloud_image_pin.test"
+
+// testAccImagePinConfig returns a pin of the SLES 15 SP1 BYOS images with a
+// maintenance window opening every Sunday at 2 AM for 4 hours
+func testAccImagePinConfig(trigger string) string {
+	return testAccProviderConfig(fmt.Sprintf(`
+resource "susepubliccloud_image_pin" "test" {
+  cloud          = "amazon"
+  region         = "eu-central-1"
+  name_regex     = "suse-sles-15-sp1-byos.*-hvm-ssd-x86_64"
+  rotate_trigger = "%s"
+
+  maintenance_window {
+    schedule = "0 2 * * SUN"
+    duration = "4h"
+  }
+}
+`, trigger))
+}
+
+// testAccSetTime sets the time seen by the provider
+func testAccSetTime(now time.Time) {
+	timeNow = func() time.Time { return now }
+}
+
+func TestAccResourceImagePin_rotateTrigger(t *testing.T) {
+	defer testAccResetCatalog(t)
+	defer func() { timeNow = time.Now }()
+
+	// Wednesday, the maintenance window is closed
+	testAccSetTime(time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	testAccTest(t,
+		testAccStep{
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImagePin, "image_name", "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImagePin, "published_on", "20190624"),
+				testAccCheckAttr(testAccImagePin, "previous_image_id", ""),
+			),
+		},
+		testAccStep{
+			// a newer image is published, the window is closed
+			PreConfig: func() { testAccPublishImage(t) },
+			Config:    testAccImagePinConfig("1"),
+			Check:     testAccCheckAttr(testAccImagePin, "image_id", "ami-0f9515259be7cd031"),
+		},
+		testAccStep{
+			Config: testAccImagePinConfig("2"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImagePin, "image_name", "suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64"),
+				testAccCheckAttr(testAccImagePin, "published_on", "20191010"),
+				testAccCheckAttr(testAccImagePin, "previous_image_id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImagePin, "previous_image_name", "suse-sles-15-sp1-byos-v20190624-hvm-ssd-x86_64"),
+			),
+		},
+	)
+}
+
+func TestAccResourceImagePin_maintenanceWindow(t *testing.T) {
+	defer testAccResetCatalog(t)
+	defer func() { timeNow = time.Now }()
+
+	testAccTest(t,
+		testAccStep{
+			// Wednesday, the maintenance window is closed
+			PreConfig: func() { testAccSetTime(time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)) },
+			Config:    testAccImagePinConfig("1"),
+			Check:     testAccCheckAttr(testAccImagePin, "image_id", "ami-0f9515259be7cd031"),
+		},
+		testAccStep{
+			// Sunday 3 AM, the maintenance window is open
+			PreConfig: func() {
+				testAccPublishImage(t)
+				testAccSetTime(time.Date(2024, 1, 14, 3, 0, 0, 0, time.UTC))
+			},
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImagePin, "previous_image_id", "ami-0f9515259be7cd031"),
+			),
+		},
+		testAccStep{
+			// nothing changes while the window is still open
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImagePin, "previous_image_id", "ami-0f9515259be7cd031"),
+			),
+		},
+	)
+}
+
+func TestAccResourceImagePin_errors(t *testing.T) {
+	testAccTest(t,
+		testAccStep{
+			Config: testAccProviderConfig(`
+resource "susepubliccloud_image_pin" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+
+  maintenance_window {
+    schedule = "0 25 * * SUN"
+    duration = "4h"
+  }
+}
+`),
+			ExpectError: regexp.MustCompile(`invalid hour "25"`),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+resource "susepubliccloud_image_pin" "test" {
+  cloud  = "amazon"
+  region = "eu-central-1"
+
+  maintenance_window {
+    schedule  = "0 2 * * SUN"
+    duration  = "4h"
+    time_zone = "Europe/Nowhere"
+  }
+}
+`),
+			ExpectError: regexp.MustCompile(`time_zone: invalid time zone "Europe/Nowhere"`),
+		},
+		testAccStep{
+			Config: testAccProviderConfig(`
+resource "susepubliccloud_image_pin" "test" {
+  cloud      = "amazon"
+  region     = "eu-central-1"
+  name_regex = "^opensuse"
+}
+`),
+			ExpectError: regexp.MustCompile(`no image matches the query of the pin`),
+		},
+	)
+}
+
+// testAccUpdatePinnedImage publishes a newer SLES 15 SP1 BYOS image, like
+// testAccPublishImage, and updates or removes the image pinned at first
+func testAccUpdatePinnedImage(t *testing.T, update func(image *images.Image) bool) {
+	catalog, err := testAccCatalog()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	kept := make([]images.Image, 0)
+	for _, image := range catalog.Images["amazon"] {
+		if image.ID == "ami-0f9515259be7cd031" && !update(&image) {
+			continue
+		}
+		kept = append(kept, image)
+	}
+	catalog.Images["amazon"] = append(kept, images.Image{
+		Name:        "suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64",
+		State:       "active",
+		PublishedOn: "20191010",
+		Region:      "eu-central-1",
+		ID:          "ami-0c0ffee0c0ffee000",
+	})
+	catalog.DataVersion["amazon"] = "2"
+	testAccServer.SetCatalog(catalog)
+}
+
+func TestAccResourceImagePin_refresh(t *testing.T) {
+	defer testAccResetCatalog(t)
+	defer func() { timeNow = time.Now }()
+
+	// Wednesday, the maintenance window is closed
+	testAccSetTime(time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	testAccTest(t,
+		testAccStep{
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImagePin, "image_state", "active"),
+				testAccCheckAttr(testAccImagePin, "deprecated_on", ""),
+			),
+		},
+		testAccStep{
+			// the deprecation is refreshed, the pin waits for the window
+			PreConfig: func() {
+				testAccUpdatePinnedImage(t, func(image *images.Image) bool {
+					image.State = "deprecated"
+					image.DeprecatedOn = "20191010"
+					image.ReplacementID = "ami-0c0ffee0c0ffee000"
+					image.ReplacementName = "suse-sles-15-sp1-byos-v20191010-hvm-ssd-x86_64"
+					return true
+				})
+			},
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0f9515259be7cd031"),
+				testAccCheckAttr(testAccImagePin, "image_state", "deprecated"),
+				testAccCheckAttr(testAccImagePin, "deprecated_on", "20191010"),
+				testAccCheckAttr(testAccImagePin, "replacement_id", "ami-0c0ffee0c0ffee000"),
+			),
+		},
+		testAccStep{
+			// the pinned image is gone, the pin moves right away
+			PreConfig: func() {
+				testAccUpdatePinnedImage(t, func(image *images.Image) bool { return false })
+			},
+			Config: testAccImagePinConfig("1"),
+			Check: testAccComposeCheck(
+				testAccCheckAttr(testAccImagePin, "image_id", "ami-0c0ffee0c0ffee000"),
+				testAccCheckAttr(testAccImagePin, "image_state", "active"),
+				testAccCheckAttr(testAccImagePin, "replacement_id", ""),
+				testAccCheckAttr(testAccImagePin, "previous_image_id", "ami-0f9515259be7cd031"),
+			),
+		},
+	)
+}
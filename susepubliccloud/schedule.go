@@ -0,0 +1,198 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeNow returns the current time, replaced by the tests
+var timeNow = time.Now
+
+// maxWindowDuration is the longest maintenance window accepted
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// cronSchedule is a cron-like schedule made of five fields:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field accepts "*", single values, ranges ("1-5"), lists ("1,3,5")
+// and steps ("*/15", "0-30/10"). Months and days of the week can also be
+// written using their three letters English names, like "JAN" or "SUN".
+// Like cron, when both day-of-month and day-of-week are restricted a time
+// matches if either of them matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+	domAny, dowAny                bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12,
+		names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day of week", min: 0, max: 7,
+		names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// parseCronSchedule parses a schedule made of five space separated fields
+func parseCronSchedule(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields, got %d",
+			spec, len(cronFields), len(fields))
+	}
+
+	values := make([][]bool, len(fields))
+	for i, field := range fields {
+		v, err := cronFields[i].parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+		}
+		values[i] = v
+	}
+
+	// both 0 and 7 are Sunday
+	values[4][0] = values[4][0] || values[4][7]
+
+	return &cronSchedule{
+		minute: values[0],
+		hour:   values[1],
+		dom:    values[2],
+		month:  values[3],
+		dow:    values[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected a value between %d and %d",
+			f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+func (f cronField) parse(field string) ([]bool, error) {
+	values := make([]bool, f.max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %s %q", f.name, part)
+			}
+			step = s
+			part = part[:i]
+		}
+
+		low, high := f.min, f.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return nil, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = f.value(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				high = f.max
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid %s range %q", f.name, part)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// matches returns true when the schedule fires at the given minute
+func (c *cronSchedule) matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// maintenanceWindow is a window of time that opens at every activation of
+// a schedule and stays open for a given duration
+type maintenanceWindow struct {
+	schedule *cronSchedule
+	duration time.Duration
+	location *time.Location
+}
+
+func newMaintenanceWindow(schedule, duration, timeZone string) (*maintenanceWindow, error) {
+	s, err := parseCronSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %v", duration, err)
+	}
+	if d < time.Minute || d > maxWindowDuration {
+		return nil, fmt.Errorf("invalid duration %q: expected a value between 1m and %s",
+			duration, maxWindowDuration)
+	}
+
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", timeZone, err)
+	}
+
+	return &maintenanceWindow{schedule: s, duration: d, location: location}, nil
+}
+
+// isOpen returns true when the window is open at the given time
+func (w *maintenanceWindow) isOpen(now time.Time) bool {
+	now = now.In(w.location)
+	start := now.Truncate(time.Minute)
+
+	for t := start; now.Sub(t) < w.duration; t = t.Add(-time.Minute) {
+		if w.schedule.matches(t) {
+			return true
+		}
+	}
+
+	return false
+}
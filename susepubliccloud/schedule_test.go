@@ -0,0 +1,61 @@
+package susepubliccloud
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowIsOpen(t *testing.T) {
+	// Sunday 2024-01-07
+	sunday := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		duration string
+		timeZone string
+		now      time.Time
+		expected bool
+	}{
+		{"0 2 * * SUN", "4h", "UTC", sunday.Add(3 * time.Hour), true},
+		{"0 2 * * SUN", "4h", "UTC", sunday.Add(6 * time.Hour), false},
+		{"0 2 * * SUN", "4h", "UTC", sunday.Add(time.Hour), false},
+		{"0 2 * * 0", "4h", "UTC", sunday.Add(2 * time.Hour), true},
+		{"0 2 * * 7", "4h", "UTC", sunday.Add(2 * time.Hour), true},
+		{"0 2 * * MON-FRI", "4h", "UTC", sunday.Add(3 * time.Hour), false},
+		{"0 22 * * SAT", "4h", "UTC", sunday.Add(time.Hour), true},
+		{"*/15 * * * *", "5m", "UTC", sunday.Add(17 * time.Minute), true},
+		{"*/15 * * * *", "5m", "UTC", sunday.Add(22 * time.Minute), false},
+		{"0 0 1 JAN,JUL *", "24h", "UTC", sunday.Add(-6 * 24 * time.Hour), true},
+		{"0 0 1 JAN,JUL *", "24h", "UTC", sunday, false},
+		{"0 2 * * SUN", "1h", "Europe/Berlin", sunday.Add(time.Hour + 30*time.Minute), true},
+	}
+
+	for _, test := range tests {
+		w, err := newMaintenanceWindow(test.schedule, test.duration, test.timeZone)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if w.isOpen(test.now) != test.expected {
+			t.Errorf("Unexpected state of window %q (%s) at %s. Got %v, expected %v",
+				test.schedule, test.duration, test.now, !test.expected, test.expected)
+		}
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, schedule := range []string{
+		"",
+		"0 2 * *",
+		"60 2 * * *",
+		"0 24 * * *",
+		"0 2 0 * *",
+		"0 2 * 13 *",
+		"0 2 * * FUNDAY",
+		"0 5-2 * * *",
+		"*/0 * * * *",
+	} {
+		if _, err := parseCronSchedule(schedule); err == nil {
+			t.Errorf("Parsing of %q should have failed", schedule)
+		}
+	}
+}
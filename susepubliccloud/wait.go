@@ -0,0 +1,172 @@
+package susepubliccloud
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Defaults of the wait_for block
+const (
+	defaultWaitPollInterval = 30 * time.Second
+	defaultWaitTimeout      = 10 * time.Minute
+)
+
+// timeSleep pauses between two polls of the info service, replaced by the
+// tests
+var timeSleep = time.Sleep
+
+// waitForSchema returns the schema of the wait_for block of the image data
+// sources
+func waitForSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"min_count": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      1,
+					ValidateFunc: validation.IntAtLeast(1),
+				},
+				"poll_interval": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      defaultWaitPollInterval.String(),
+					ValidateFunc: validateDuration,
+				},
+				"timeout": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      defaultWaitTimeout.String(),
+					ValidateFunc: validateDuration,
+				},
+			},
+		},
+	}
+}
+
+// waitFor holds the settings of the wait_for block
+type waitFor struct {
+	MinCount     int
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// expandWaitFor returns the settings of the wait_for block, nil when the
+// block is not set
+func expandWaitFor(d schemaGetter) *waitFor {
+	v, ok := d.GetOk("wait_for")
+	if !ok || len(v.([]interface{})) == 0 {
+		return nil
+	}
+
+	w := &waitFor{
+		MinCount:     1,
+		PollInterval: defaultWaitPollInterval,
+		Timeout:      defaultWaitTimeout,
+	}
+	block, ok := v.([]interface{})[0].(map[string]interface{})
+	if !ok {
+		// all the arguments of the block have been left to their defaults
+		return w
+	}
+	if n, ok := block["min_count"].(int); ok && n > 0 {
+		w.MinCount = n
+	}
+	// already validated by the schema
+	if d, err := time.ParseDuration(block["poll_interval"].(string)); err == nil {
+		w.PollInterval = d
+	}
+	if d, err := time.ParseDuration(block["timeout"].(string)); err == nil {
+		w.Timeout = d
+	}
+	return w
+}
+
+// isRetryable tells whether the query failing with err can succeed later,
+// like when the info service is temporarily unavailable. The errors caused
+// by the configuration, the images rejected by the selection policy and the
+// documents that cannot be decoded are not retryable.
+func isRetryable(err error) bool {
+	var httpErr *images.HTTPError
+	switch {
+	case errors.Is(err, images.ErrUnknownProvider),
+		errors.Is(err, images.ErrUnknownRegion),
+		errors.Is(err, images.ErrInvalidFilter),
+		errors.Is(err, images.ErrInvalidNameRegex),
+		errors.Is(err, images.ErrDecode),
+		errors.Is(err, images.ErrPolicyViolation):
+		return false
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode != http.StatusUnauthorized && httpErr.StatusCode != http.StatusForbidden
+	}
+	return true
+}
+
+// waitForImages polls the info service until the query selects at least
+// MinCount images. The query fails when they are not found before Timeout,
+// the error lists the images found by the last poll. The info service is
+// queried once when w is nil.
+func (c *Config) waitForImages(params images.SearchParams, w *waitFor) ([]images.Image, string, error) {
+	if w == nil {
+		return c.searchImages(params)
+	}
+
+	deadline := timeNow().Add(w.Timeout)
+	for {
+		found, servedBy, err := c.searchImages(params)
+		switch {
+		case err == nil && len(found) >= w.MinCount:
+			return found, servedBy, nil
+		case err != nil && !isRetryable(err):
+			return nil, "", err
+		}
+
+		if !timeNow().Add(w.PollInterval).Before(deadline) {
+			return nil, "", waitTimeoutError(w, found, err)
+		}
+		if err != nil {
+			log.Printf("[WARN] Cannot query the info service, retrying in %s: %v", w.PollInterval, err)
+		} else {
+			log.Printf("[INFO] Waiting for %d images matching %+v, %d found so far, retrying in %s",
+				w.MinCount, params, len(found), w.PollInterval)
+		}
+		timeSleep(w.PollInterval)
+
+		if c.PreloadCatalog {
+			// the images published meanwhile are only found by a fresh catalog
+			if err := c.catalog(params).Refresh(); err != nil {
+				log.Printf("[WARN] Cannot refresh the %s catalog: %v", params.Cloud, err)
+			}
+		}
+	}
+}
+
+// waitTimeoutError describes the images found by the last poll, together
+// with its error
+func waitTimeoutError(w *waitFor, found []images.Image, err error) error {
+	msg := fmt.Sprintf("wait_for: timed out after %s waiting for at least %d images", w.Timeout, w.MinCount)
+	if err != nil {
+		return fmt.Errorf("%s, the last query failed: %w", msg, err)
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("%s, none found", msg)
+	}
+
+	names := make([]string, 0, len(found))
+	for _, image := range found {
+		names = append(names, fmt.Sprintf("%s (%s, published on %s)",
+			image.Name, image.ID, formatImageDate(image.PublishedOn)))
+	}
+	return fmt.Errorf("%s, %d found:\n  %s", msg, len(found), strings.Join(names, "\n  "))
+}
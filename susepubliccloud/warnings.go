@@ -0,0 +1,85 @@
+package susepubliccloud
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+// deprecationWarnings returns a warning for each image that is deprecated or
+// that has a deprecation date set
+func deprecationWarnings(found []images.Image) []string {
+	warnings := make([]string, 0)
+
+	for _, image := range found {
+		if image.State != "deprecated" && image.DeprecatedOn == "" {
+			continue
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("image %s (%s)", image.Name, image.ID))
+
+		deprecatedOn := formatImageDate(image.DeprecatedOn)
+		switch {
+		case deprecatedOn == "":
+			sb.WriteString(" is deprecated")
+		case image.State == "deprecated" || !isFutureDate(image.DeprecatedOn):
+			sb.WriteString(fmt.Sprintf(" is deprecated since %s", deprecatedOn))
+		default:
+			sb.WriteString(fmt.Sprintf(" will be deprecated on %s", deprecatedOn))
+		}
+
+		if deletedOn := formatImageDate(image.DeletedOn); deletedOn != "" {
+			sb.WriteString(fmt.Sprintf(" and will be deleted on %s", deletedOn))
+		}
+
+		switch {
+		case image.ReplacementName != "" && image.ReplacementID != "":
+			sb.WriteString(fmt.Sprintf(", it is replaced by %s (%s)", image.ReplacementName, image.ReplacementID))
+		case image.ReplacementName != "":
+			sb.WriteString(fmt.Sprintf(", it is replaced by %s", image.ReplacementName))
+		case image.ReplacementID != "":
+			sb.WriteString(fmt.Sprintf(", it is replaced by %s", image.ReplacementID))
+		}
+
+		warnings = append(warnings, sb.String())
+	}
+
+	return warnings
+}
+
+// checkDeprecations logs the deprecation warnings of the images. An error
+// is returned instead when the provider is configured to reject deprecated
+// images. The SDK doesn't let the Read of a data source return warning
+// diagnostics, the warnings are exposed by the warnings attribute.
+func (c *Config) checkDeprecations(found []images.Image) ([]string, error) {
+	warnings := deprecationWarnings(found)
+	if len(warnings) > 0 && c.FailOnDeprecatedImages {
+		return warnings, fmt.Errorf("deprecated images selected:\n  %s",
+			strings.Join(warnings, "\n  "))
+	}
+
+	for _, w := range warnings {
+		log.Printf("[WARN] %s", w)
+	}
+
+	return warnings, nil
+}
+
+// formatImageDate converts the dates returned by the API, like "20190624",
+// into the "2019-06-24" format
+func formatImageDate(s string) string {
+	t, err := time.Parse(images.PublishedOnLayout, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("2006-01-02")
+}
+
+func isFutureDate(s string) bool {
+	t, err := time.Parse(images.PublishedOnLayout, s)
+	return err == nil && t.After(time.Now())
+}
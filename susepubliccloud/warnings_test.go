@@ -0,0 +1,46 @@
+package susepubliccloud
+
+import (
+	"testing"
+
+	images "github.com/SUSE/terraform-provider-susepubliccloud/pkg/info-service"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	tests := []struct {
+		image    images.Image
+		expected string
+	}{
+		{
+			images.Image{Name: "sles-a", ID: "ami-1", State: "active"},
+			"",
+		},
+		{
+			images.Image{Name: "sles-a", ID: "ami-1", State: "deprecated"},
+			"image sles-a (ami-1) is deprecated",
+		},
+		{
+			images.Image{Name: "sles-a", ID: "ami-1", State: "deprecated", DeprecatedOn: "20190624",
+				DeletedOn: "20191224", ReplacementName: "sles-b", ReplacementID: "ami-2"},
+			"image sles-a (ami-1) is deprecated since 2019-06-24 and will be deleted on 2019-12-24, it is replaced by sles-b (ami-2)",
+		},
+		{
+			images.Image{Name: "sles-a", ID: "ami-1", State: "active", DeprecatedOn: "20190624", ReplacementID: "ami-2"},
+			"image sles-a (ami-1) is deprecated since 2019-06-24, it is replaced by ami-2",
+		},
+		{
+			images.Image{Name: "sles-a", ID: "ami-1", State: "active", DeprecatedOn: "29990101", ReplacementName: "sles-b"},
+			"image sles-a (ami-1) will be deprecated on 2999-01-01, it is replaced by sles-b",
+		},
+	}
+
+	for _, test := range tests {
+		warnings := deprecationWarnings([]images.Image{test.image})
+		switch {
+		case test.expected == "" && len(warnings) != 0:
+			t.Fatalf("Unexpected warnings %v", warnings)
+		case test.expected != "" && (len(warnings) != 1 || warnings[0] != test.expected):
+			t.Fatalf("Unexpected warnings. Got %v, expected %q", warnings, test.expected)
+		}
+	}
+}